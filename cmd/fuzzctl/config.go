@@ -0,0 +1,287 @@
+// cmd/fuzzctl/config.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check [file]",
+	Short: "Validate a configuration file and report every problem",
+	Args:  cobra.MaximumNArgs(1),
+	// Problems are reported through the exit status, not misuse
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			cmd.Flags().Set("config", args[0])
+		}
+
+		cfg, err := readConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		var problems []*config.FieldError
+		var verr *config.ValidationError
+		if err := cfg.Validate(); errors.As(err, &verr) {
+			problems = verr.Errors
+		} else if err != nil {
+			return err
+		}
+
+		for _, p := range problems {
+			fmt.Printf("error: %s\n", p)
+		}
+
+		// Keys can only be checked against targets if discovery works
+		if len(problems) == 0 {
+			targets, err := discoverTargets(cfg)
+			if err != nil {
+				return err
+			}
+
+			var names []string
+			for _, t := range targets {
+				names = append(names, t.Package+"."+t.Name)
+			}
+
+			for _, w := range cfg.UnusedKeys(names) {
+				fmt.Printf("warning: %s\n", w)
+			}
+			if w := cfg.OverAllocation(names); w != nil {
+				fmt.Printf("warning: %s\n", w)
+			}
+		}
+
+		if len(problems) > 0 {
+			return fmt.Errorf("configuration has %d problems", len(problems))
+		}
+
+		fmt.Println("Configuration OK")
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+}
+
+// loadConfig builds and validates the configuration for a command
+func loadConfig(cmd *cobra.Command, args []string) (*config.Config, error) {
+	cfg, err := readConfig(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// readConfig builds the configuration for a command. Settings are applied in
+// order of precedence: defaults, the --config file (or $FUZZCTL_CONFIG),
+// FUZZCTL_* environment variables, and finally flags and package arguments
+// given on the command line.
+func readConfig(cmd *cobra.Command, args []string) (*config.Config, error) {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		path = os.Getenv(config.EnvPrefix + "CONFIG")
+	}
+
+	cfg := config.Default()
+	if path != "" {
+		var err error
+		if cfg, err = config.LoadFromFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	applyFlags(cmd, cfg)
+
+	// Use provided packages or the configured ones
+	if len(args) > 0 {
+		cfg.Packages = args
+	}
+
+	return cfg, nil
+}
+
+// applyFlags overrides settings with the flags explicitly set on the command
+// line. Flags a command doesn't define are never reported as changed.
+func applyFlags(cmd *cobra.Command, cfg *config.Config) {
+	flags := cmd.Flags()
+
+	if flags.Changed("root-dir") {
+		cfg.RootDir, _ = flags.GetString("root-dir")
+	}
+	if flags.Changed("all-modules") {
+		cfg.AllModules, _ = flags.GetBool("all-modules")
+	}
+	if flags.Changed("tags") {
+		cfg.BuildTags, _ = flags.GetStringSlice("tags")
+	}
+	if flags.Changed("corpus") {
+		cfg.CorpusDir, _ = flags.GetString("corpus")
+	}
+	if flags.Changed("crash-dir") {
+		cfg.CrashDir, _ = flags.GetString("crash-dir")
+	}
+	if flags.Changed("crash-lines") {
+		cfg.CrashSignatureLines, _ = flags.GetBool("crash-lines")
+	}
+	if flags.Changed("time") {
+		cfg.FuzzTime, _ = flags.GetDuration("time")
+	}
+	if flags.Changed("budget") {
+		cfg.Budget, _ = flags.GetDuration("budget")
+	}
+	if flags.Changed("strategy") {
+		cfg.Strategy, _ = flags.GetString("strategy")
+	}
+	if flags.Changed("round-time") {
+		cfg.RoundTime, _ = flags.GetDuration("round-time")
+	}
+	if flags.Changed("parallel") {
+		cfg.Parallelism, _ = flags.GetInt("parallel")
+	}
+	if flags.Changed("jobs") {
+		cfg.ConcurrentTargets, _ = flags.GetInt("jobs")
+	}
+	if flags.Changed("changed-only") {
+		cfg.ChangedOnly, _ = flags.GetBool("changed-only")
+	}
+	if flags.Changed("git-ref") {
+		cfg.GitRef, _ = flags.GetString("git-ref")
+	}
+	if flags.Changed("impact") {
+		cfg.Impact, _ = flags.GetString("impact")
+	}
+	if flags.Changed("include") {
+		cfg.Include, _ = flags.GetStringArray("include")
+	}
+	if flags.Changed("exclude") {
+		cfg.Exclude, _ = flags.GetStringArray("exclude")
+	}
+	if flags.Changed("only-tags") {
+		cfg.OnlyTags, _ = flags.GetStringSlice("only-tags")
+	}
+	if flags.Changed("skip-tags") {
+		cfg.SkipTags, _ = flags.GetStringSlice("skip-tags")
+	}
+	if flags.Changed("no-cache") {
+		noCache, _ := flags.GetBool("no-cache")
+		cfg.DiscoveryCache = !noCache
+		cfg.BinaryCache = !noCache
+	}
+	if flags.Changed("report-dir") {
+		cfg.ReportDir, _ = flags.GetString("report-dir")
+	}
+}
+
+// discoverTargets finds the fuzz targets selected by the configuration
+func discoverTargets(cfg *config.Config) ([]*target.Target, error) {
+	targets, err := findTargets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectTargets(cfg, targets)
+}
+
+// findTargets discovers all fuzz targets of the configured packages, before
+// any filters are applied
+func findTargets(cfg *config.Config) ([]*target.Target, error) {
+	options := target.DiscoveryOptions{
+		RootDir:     cfg.RootDir,
+		Patterns:    cfg.Packages,
+		ChangedOnly: cfg.ChangedOnly,
+		GitRef:      cfg.GitRef,
+		BuildTags:   cfg.BuildTags,
+		AllModules:  cfg.AllModules,
+
+		FunctionImpact: cfg.Impact == config.ImpactFunction,
+	}
+
+	// Without a user cache directory, e.g. without $HOME, targets are
+	// discovered from scratch
+	if cfg.DiscoveryCache {
+		if dir, err := os.UserCacheDir(); err == nil {
+			options.CacheDir = filepath.Join(dir, "go-fuzz-runner", "discovery")
+		}
+	}
+
+	targets, err := target.DiscoverTargets(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// selectTargets applies the configured filters, //fuzz:skip annotations and
+// per-target overrides to discovered targets
+func selectTargets(cfg *config.Config, targets []*target.Target) ([]*target.Target, error) {
+	filter, err := targetFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the filters and per-target overrides
+	selected := make([]*target.Target, 0, len(targets))
+	for _, t := range filter.Apply(targets) {
+		tc, ok := cfg.Target(t.Package, t.Name)
+		if ok && tc.Skip {
+			continue
+		}
+		if !cfg.HarnessDetection && !ok {
+			continue
+		}
+		selected = append(selected, t)
+	}
+
+	return selected, nil
+}
+
+// targetFilter builds the filter selecting targets by name and annotations
+func targetFilter(cfg *config.Config) (*target.Filter, error) {
+	return target.NewFilter(target.FilterOptions{
+		Include:  cfg.Include,
+		Exclude:  cfg.Exclude,
+		OnlyTags: cfg.OnlyTags,
+		SkipTags: cfg.SkipTags,
+	})
+}
+
+// addCrashDirFlag registers the flag setting where failing inputs are
+// stored, which follows the corpus directory by default
+func addCrashDirFlag(flags *pflag.FlagSet) {
+	flags.String("crash-dir", "", "Directory of stored failing inputs (default <corpus>/crashers)")
+}
+
+// addFilterFlags registers the flags that select targets by name and tag
+func addFilterFlags(flags *pflag.FlagSet) {
+	flags.StringArray("include", nil, "Only use targets whose <package>.<FuzzName> matches this regular expression (repeatable)")
+	flags.StringArray("exclude", nil, "Leave out targets whose <package>.<FuzzName> matches this regular expression (repeatable)")
+	flags.StringSlice("only-tags", nil, "Only use targets with one of these //fuzz:tags (comma-separated)")
+	flags.StringSlice("skip-tags", nil, "Leave out targets with any of these //fuzz:tags (comma-separated)")
+}
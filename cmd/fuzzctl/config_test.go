@@ -0,0 +1,116 @@
+// cmd/fuzzctl/config_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestReadConfig(t *testing.T) {
+	const file = `packages: [./from-file/...]
+fuzz_time: 2m
+parallelism: 8
+corpus_dir: ./from-file
+`
+
+	tests := []struct {
+		name     string
+		file     bool
+		env      map[string]string
+		flags    []string
+		args     []string
+		time     time.Duration
+		parallel int
+		corpus   string
+		packages []string
+	}{
+		{
+			name:     "defaults",
+			time:     5 * time.Minute,
+			parallel: 4,
+			corpus:   "./fuzz-corpus",
+			packages: []string{"./..."},
+		},
+		{
+			name:     "file",
+			file:     true,
+			time:     2 * time.Minute,
+			parallel: 8,
+			corpus:   "./from-file",
+			packages: []string{"./from-file/..."},
+		},
+		{
+			name:     "environment",
+			file:     true,
+			env:      map[string]string{"FUZZCTL_FUZZ_TIME": "3m", "FUZZCTL_CORPUS_DIR": "./from-env"},
+			time:     3 * time.Minute,
+			parallel: 8,
+			corpus:   "./from-env",
+			packages: []string{"./from-file/..."},
+		},
+		{
+			name:     "flags",
+			file:     true,
+			env:      map[string]string{"FUZZCTL_FUZZ_TIME": "3m", "FUZZCTL_CORPUS_DIR": "./from-env"},
+			flags:    []string{"--time", "4m", "--parallel", "2"},
+			args:     []string{"./from-args/..."},
+			time:     4 * time.Minute,
+			parallel: 2,
+			corpus:   "./from-env",
+			packages: []string{"./from-args/..."},
+		},
+		{
+			name:     "flags set to their defaults",
+			file:     true,
+			env:      map[string]string{"FUZZCTL_PARALLELISM": "6"},
+			flags:    []string{"--time", "5m", "--parallel", "4"},
+			time:     5 * time.Minute,
+			parallel: 4,
+			corpus:   "./from-file",
+			packages: []string{"./from-file/..."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cmd := &cobra.Command{}
+			cmd.Flags().String("config", "", "")
+			cmd.Flags().DurationP("time", "t", 5*time.Minute, "")
+			cmd.Flags().IntP("parallel", "p", 4, "")
+			cmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "")
+
+			flags := tt.flags
+			if tt.file {
+				path := filepath.Join(t.TempDir(), "fuzzctl.yaml")
+				if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+					t.Fatal(err)
+				}
+				flags = append(flags, "--config", path)
+			}
+			if err := cmd.ParseFlags(flags); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := readConfig(cmd, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.FuzzTime != tt.time || cfg.Parallelism != tt.parallel || cfg.CorpusDir != tt.corpus {
+				t.Errorf("fuzz_time, parallelism, corpus_dir = %v, %d, %s, want %v, %d, %s",
+					cfg.FuzzTime, cfg.Parallelism, cfg.CorpusDir, tt.time, tt.parallel, tt.corpus)
+			}
+			if !slices.Equal(cfg.Packages, tt.packages) {
+				t.Errorf("packages = %v, want %v", cfg.Packages, tt.packages)
+			}
+		})
+	}
+}
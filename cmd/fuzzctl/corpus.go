@@ -4,11 +4,18 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/OmBiradar/go-fuzz-runner/internal/codec"
 	"github.com/OmBiradar/go-fuzz-runner/internal/corpus"
+	"github.com/OmBiradar/go-fuzz-runner/internal/runner"
 	"github.com/OmBiradar/go-fuzz-runner/internal/target"
 )
 
@@ -21,115 +28,531 @@ var corpusListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List corpus statistics",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		corpusDir, _ := cmd.Flags().GetString("corpus")
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
 
 		// Create corpus manager
-		cm, err := corpus.NewCorpusManager(corpusDir, corpus.NoMinimization)
+		cm, err := corpus.NewCorpusManager(cfg.CorpusDir)
 		if err != nil {
 			return fmt.Errorf("failed to create corpus manager: %w", err)
 		}
 
 		// Discover targets
-		targets, err := target.DiscoverTargets(target.DiscoveryOptions{
-			RootDir:  ".",
-			Patterns: []string{"./..."},
-		})
+		targets, err := discoverTargets(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to discover targets: %w", err)
+			return err
 		}
 
 		// Print corpus stats
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "TARGET\tCORPUS ITEMS\tDIRECTORY")
+		fmt.Fprintln(w, "TARGET\tCORPUS ITEMS\tBYTES\tLAST ADDED\tDIRECTORY")
 
+		var (
+			entries int
+			size    int64
+		)
 		for _, t := range targets {
-			dir := cm.GetTargetDir(t)
-			count := countFiles(dir)
+			idx, err := cm.Index(t)
+			if err != nil {
+				return err
+			}
+			entries += len(idx.Entries)
+			size += idx.Size()
+
+			lastAdded := "-"
+			if last := idx.LastAdded(); !last.IsZero() {
+				lastAdded = last.Local().Format(time.DateTime)
+			}
+
+			fmt.Fprintf(w, "%s.%s\t%d\t%d\t%s\t%s\n",
+				t.Package, t.Name, len(idx.Entries), idx.Size(), lastAdded, cm.GetTargetDir(t))
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		// Identical inputs of different targets are stored once
+		objects, objectSize, err := cm.ObjectStats()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n%d entries (%d bytes), stored as %d distinct inputs (%d bytes) for all targets\n",
+			entries, size, objects, objectSize)
+
+		return nil
+	},
+}
+
+var corpusPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete stored inputs no target refers to",
+	Long: `prune deletes the stored contents of inputs that no target's index lists,
+such as those left behind when writing an index failed. Inputs removed by
+corpus minimize, or deleted from a target directory by hand, are deleted
+as soon as no other target has them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		cm, err := corpus.NewCorpusManager(cfg.CorpusDir)
+		if err != nil {
+			return fmt.Errorf("failed to create corpus manager: %w", err)
+		}
 
-			fmt.Fprintf(w, "%s.%s\t%d\t%s\n",
-				t.Package, t.Name, count, dir)
+		count, size, err := cm.Prune()
+		if err != nil {
+			return err
 		}
+		fmt.Printf("Pruned %d stored inputs (%d bytes)\n", count, size)
 
-		return w.Flush()
+		return nil
 	},
 }
 
 var corpusMinimizeCmd = &cobra.Command{
 	Use:   "minimize [targets]",
 	Short: "Minimize corpus for targets",
+	Long: `minimize replays every corpus entry of the targets on its own through a
+coverage-instrumented test binary and keeps the smallest set of entries that
+together cover the same code, preferring smaller entries. Entries that fail
+are kept.
+
+Coverage is an approximation of what the fuzzer sees: go test's count-mode
+block coverage of the module, with hit counts bucketed (1, 2, 3, 4-7, 8-15,
+16-31, 32-127, 128+) like fuzzer counters. The fuzzer's own edge counters
+aren't available outside of go test, and blocks are coarser than edges, so
+a few entries the fuzzer kept as interesting may be removed. Use --dry-run
+to see the effect first.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		corpusDir, _ := cmd.Flags().GetString("corpus")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
-		// Create corpus manager with minimization
-		cm, err := corpus.NewCorpusManager(corpusDir, corpus.CoverageMinimization)
+		cfg, err := loadConfig(cmd, nil)
 		if err != nil {
-			return fmt.Errorf("failed to create corpus manager: %w", err)
+			return err
 		}
 
 		// Discover targets
-		targets, err := target.DiscoverTargets(target.DiscoveryOptions{
-			RootDir:  ".",
-			Patterns: []string{"./..."},
-		})
+		targets, err := discoverTargets(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to discover targets: %w", err)
+			return err
 		}
 
-		// Filter targets if args provided
+		// Limit to the packages and targets given as arguments
 		if len(args) > 0 {
-			filtered := make([]*target.Target, 0)
-			for _, t := range targets {
-				for _, arg := range args {
-					if t.Package == arg ||
-						fmt.Sprintf("%s.%s", t.Package, t.Name) == arg {
-						filtered = append(filtered, t)
-					}
-				}
+			filter, err := target.NewFilter(target.FilterOptions{Names: args})
+			if err != nil {
+				return err
 			}
-			targets = filtered
+			targets = filter.Apply(targets)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		engine, err := runner.NewFuzzEngine(ctx, cfg, targets)
+		if err != nil {
+			return fmt.Errorf("failed to create fuzz engine: %w", err)
 		}
 
 		// Minimize corpus for each target
 		for _, t := range targets {
 			fmt.Printf("Minimizing corpus for %s.%s... ", t.Package, t.Name)
 
-			countBefore := countFiles(cm.GetTargetDir(t))
-
-			if err := cm.Minimize(t); err != nil {
+			result, err := engine.MinimizeTarget(ctx, t, dryRun)
+			if ctx.Err() != nil {
+				fmt.Println("INTERRUPTED")
+				return ctx.Err()
+			}
+			if err != nil {
 				fmt.Println("FAILED")
 				fmt.Printf("  Error: %v\n", err)
 				continue
 			}
 
-			countAfter := countFiles(cm.GetTargetDir(t))
+			fmt.Printf("DONE (%d → %d items, %d → %d bytes, coverage %d → %d features)\n",
+				result.Entries, result.Kept, result.Bytes, result.KeptBytes,
+				result.Coverage, result.KeptCovered)
+			if len(result.Failing) > 0 {
+				fmt.Printf("  Kept %d failing entries, see fuzzctl regress\n", len(result.Failing))
+			}
+		}
+
+		if dryRun {
+			fmt.Println("Dry run, no entries were removed")
+		}
+
+		return nil
+	},
+}
+
+var corpusShowCmd = &cobra.Command{
+	Use:   "show <file>...",
+	Short: "Show the decoded arguments of corpus files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for i, path := range args {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read corpus file: %w", err)
+			}
+
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", path)
+			printInput(data)
+		}
+
+		return nil
+	},
+}
+
+var corpusValidateCmd = &cobra.Command{
+	Use:   "validate [targets]",
+	Short: "Check that corpus entries match the arguments of their targets",
+	// Invalid entries are reported through the exit status, not misuse
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		cm, err := corpus.NewCorpusManager(cfg.CorpusDir)
+		if err != nil {
+			return fmt.Errorf("failed to create corpus manager: %w", err)
+		}
+
+		targets, err := discoverTargets(cfg)
+		if err != nil {
+			return err
+		}
+
+		// Limit to the packages and targets given as arguments
+		if len(args) > 0 {
+			filter, err := target.NewFilter(target.FilterOptions{Names: args})
+			if err != nil {
+				return err
+			}
+			targets = filter.Apply(targets)
+		}
+
+		invalid := 0
+		for _, t := range targets {
+			idx, err := cm.Index(t)
+			if err != nil {
+				return err
+			}
+
+			var problems []string
+			for _, entry := range idx.Entries {
+				data, err := os.ReadFile(filepath.Join(cm.GetTargetDir(t), entry.Hash))
+				if err != nil {
+					return fmt.Errorf("failed to read corpus entry: %w", err)
+				}
+
+				values, err := codec.Unmarshal(data)
+				// Without a known f.Fuzz call, only the encoding is checked
+				if err == nil && t.Params != nil {
+					err = codec.Check(values, t.Params)
+				}
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s: %v", entry.Hash, err))
+				}
+			}
+
+			status := "OK"
+			if len(problems) > 0 {
+				status = "INVALID"
+			}
+			fmt.Printf("%s.%s %s: %s (%d entries, %d invalid)\n",
+				t.Package, t.Name, t.Signature(), status, len(idx.Entries), len(problems))
+			for _, problem := range problems {
+				fmt.Printf("  %s\n", problem)
+			}
+
+			invalid += len(problems)
+		}
+
+		if invalid > 0 {
+			return fmt.Errorf("%d invalid corpus entries", invalid)
+		}
+
+		return nil
+	},
+}
+
+var corpusImportCmd = &cobra.Command{
+	Use:   "import [<dir>]",
+	Short: "Import a raw corpus or an exported archive into the corpus",
+	Long: `import wraps every input of a raw corpus, such as a libFuzzer, go-fuzz or
+AFL corpus, into a corpus entry of a native Go fuzz target. A target taking a
+single []byte or string gets each input as it is; for other targets, --split
+gives the number of bytes each argument takes from an input. Inputs shorter
+than the split are skipped; without a * in the split, bytes beyond it are
+dropped and the inputs that lost some are counted. Inputs the corpus already
+has are skipped.
+
+With --archive, import merges an archive written by corpus export into the
+corpus instead, for all of its targets or just --target. Entries keep when
+and how they were found, and merging an archive twice adds nothing.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		archive, _ := cmd.Flags().GetString("archive")
+		targetName, _ := cmd.Flags().GetString("target")
+
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		if archive != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("give either a directory or --archive")
+			}
+			return importArchive(cfg.CorpusDir, archive, targetName)
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("give a directory to import or --archive")
+		}
+		if targetName == "" {
+			return fmt.Errorf("--target is required to import a raw corpus")
+		}
+
+		from, _ := cmd.Flags().GetString("from")
+		split, _ := cmd.Flags().GetString("split")
+		byteOrder, _ := cmd.Flags().GetString("byte-order")
+
+		targets, err := discoverTargets(cfg)
+		if err != nil {
+			return err
+		}
+		t, err := findTarget(targets, targetName)
+		if err != nil {
+			return err
+		}
+		if t.Params == nil {
+			return fmt.Errorf("the arguments of %s.%s are unknown, its f.Fuzz call wasn't found", t.Package, t.Name)
+		}
+
+		s, err := corpus.NewSplit(t.Params, split, byteOrder)
+		if err != nil {
+			return err
+		}
+
+		files, err := corpus.RawInputs(args[0], from)
+		if err != nil {
+			return err
+		}
+
+		var (
+			inputs    [][]byte
+			skipped   int
+			truncated int
+		)
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+
+			values, dropped, err := s.Values(data)
+			if err != nil {
+				skipped++
+				continue
+			}
+			if dropped > 0 {
+				truncated++
+			}
+			entry, err := codec.Marshal(values)
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, entry)
+		}
+
+		cm, err := corpus.NewCorpusManager(cfg.CorpusDir)
+		if err != nil {
+			return fmt.Errorf("failed to create corpus manager: %w", err)
+		}
+
+		added, err := cm.AddAll(t, inputs, corpus.SourceImport)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Imported %d new entries into %s.%s %s from %d files\n",
+			added, t.Package, t.Name, t.Signature(), len(files))
+		fmt.Printf("  Already in the corpus: %d\n", len(inputs)-added)
+		if skipped > 0 {
+			fmt.Printf("  Too short for the split: %d\n", skipped)
+		}
+		if truncated > 0 {
+			fmt.Printf("  Longer than the split, trailing bytes dropped: %d\n", truncated)
+		}
+
+		return nil
+	},
+}
+
+var corpusExportCmd = &cobra.Command{
+	Use:   "export [targets]",
+	Short: "Export the corpus of targets as an archive or raw files",
+	Long: `export writes the corpus entries of the targets to a .tar.gz, .tgz or .zip
+archive, or to a directory for any other --output, together with a
+manifest.json listing each target, its signature and the hash, size and
+origin of its entries. With --raw, the entries of targets taking a single
+[]byte or string are written as the bytes of that argument, as other fuzzers
+and OSS-Fuzz expect them. fuzzctl corpus import --archive merges the result
+back.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		raw, _ := cmd.Flags().GetBool("raw")
+
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		cm, err := corpus.NewCorpusManager(cfg.CorpusDir)
+		if err != nil {
+			return fmt.Errorf("failed to create corpus manager: %w", err)
+		}
+
+		targets, err := discoverTargets(cfg)
+		if err != nil {
+			return err
+		}
+
+		// Limit to the packages and targets given as arguments
+		if len(args) > 0 {
+			filter, err := target.NewFilter(target.FilterOptions{Names: args})
+			if err != nil {
+				return err
+			}
+			targets = filter.Apply(targets)
+		}
+
+		manifest, skipped, err := cm.Export(output, targets, raw)
+		if err != nil {
+			return err
+		}
 
-			fmt.Printf("DONE (%d → %d items)\n", countBefore, countAfter)
+		entries := 0
+		for _, at := range manifest.Targets {
+			fmt.Printf("  %s %s: %d entries (%s)\n", at.Target, at.Signature, len(at.Entries), at.Encoding)
+			entries += len(at.Entries)
+		}
+		fmt.Printf("Exported %d entries of %d targets to %s\n", entries, len(manifest.Targets), output)
+		if skipped > 0 {
+			fmt.Printf("Left out %d entries that don't decode to their target's argument, see fuzzctl corpus validate\n", skipped)
 		}
 
 		return nil
 	},
 }
 
+// importArchive merges the targets of an exported corpus, or just the
+// named one, into the corpus in dir
+func importArchive(dir, path, targetName string) error {
+	archive, err := corpus.OpenArchive(path)
+	if err != nil {
+		return err
+	}
+
+	archived := archive.Manifest.Targets
+	if targetName != "" {
+		targets := make([]*target.Target, len(archived))
+		for i, at := range archived {
+			targets[i] = at.FuzzTarget()
+		}
+		t, err := findTarget(targets, targetName)
+		if err != nil {
+			return err
+		}
+		i := slices.Index(targets, t)
+		archived = archived[i : i+1]
+	}
+
+	cm, err := corpus.NewCorpusManager(dir)
+	if err != nil {
+		return fmt.Errorf("failed to create corpus manager: %w", err)
+	}
+
+	total := 0
+	for _, at := range archived {
+		added, err := cm.Merge(archive, at)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %s %s: %d new of %d entries\n", at.Target, at.Signature, added, len(at.Entries))
+		total += added
+	}
+	fmt.Printf("Merged %d new entries from %d targets of %s\n", total, len(archived), path)
+
+	return nil
+}
+
 func init() {
 	corpusCmd.AddCommand(corpusListCmd)
 	corpusCmd.AddCommand(corpusMinimizeCmd)
+	corpusCmd.AddCommand(corpusPruneCmd)
+	corpusCmd.AddCommand(corpusShowCmd)
+	corpusCmd.AddCommand(corpusValidateCmd)
+	corpusCmd.AddCommand(corpusImportCmd)
+	corpusCmd.AddCommand(corpusExportCmd)
 
+	corpusCmd.PersistentFlags().StringP("root-dir", "r", ".", "Root directory of the project")
+	corpusCmd.PersistentFlags().Bool("all-modules", false, "Without a go.work workspace, use every module below the root directory, not only its own")
+	corpusCmd.PersistentFlags().StringSlice("tags", nil, "Build tags to discover, build and run fuzz targets with (comma-separated)")
+	addFilterFlags(corpusCmd.PersistentFlags())
 	corpusListCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
 	corpusMinimizeCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
+	corpusPruneCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
+	corpusValidateCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
+	corpusImportCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
+	corpusImportCmd.Flags().String("from", corpus.FormatRaw, "Format of the imported corpus: raw, go-fuzz or afl")
+	corpusImportCmd.Flags().String("target", "", "Target to import into, as <package>.<FuzzName>; with --archive, the only target to merge")
+	corpusImportCmd.Flags().String("split", "", "Bytes of an input each argument takes, comma-separated, with * for the rest, e.g. 4,*")
+	corpusImportCmd.Flags().String("byte-order", "big", "Byte order of numbers split from inputs: big or little")
+	corpusImportCmd.Flags().String("archive", "", "Merge an archive or directory written by corpus export")
+	corpusExportCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
+	corpusExportCmd.Flags().StringP("output", "o", "", "Archive (.tar.gz, .tgz or .zip) or directory to write")
+	corpusExportCmd.Flags().Bool("raw", false, "Write the entries of single []byte or string targets as raw bytes")
+	corpusExportCmd.MarkFlagRequired("output")
+	corpusMinimizeCmd.Flags().Bool("dry-run", false, "Report what would be removed without removing it")
+	corpusMinimizeCmd.Flags().IntP("parallel", "p", 4, "Number of corpus entries to replay at the same time")
 }
 
-func countFiles(dir string) int {
-	entries, err := os.ReadDir(dir)
+// findTarget returns the target with the given name, <package>.<FuzzName>,
+// the only target of a package or the only target with a FuzzName
+func findTarget(targets []*target.Target, name string) (*target.Target, error) {
+	filter, err := target.NewFilter(target.FilterOptions{Names: []string{name}})
 	if err != nil {
-		return 0
+		return nil, err
 	}
 
-	count := 0
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			count++
+	matched := filter.Apply(targets)
+	if len(matched) == 0 {
+		for _, t := range targets {
+			if t.Name == name {
+				matched = append(matched, t)
+			}
 		}
 	}
-
-	return count
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("no fuzz target %s", name)
+	case 1:
+		return matched[0], nil
+	}
+	return nil, fmt.Errorf("%s matches %d targets, use <package>.<FuzzName>", name, len(matched))
 }
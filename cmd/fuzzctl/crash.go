@@ -0,0 +1,342 @@
+// cmd/fuzzctl/crash.go
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/codec"
+	"github.com/OmBiradar/go-fuzz-runner/internal/crash"
+	"github.com/OmBiradar/go-fuzz-runner/internal/runner"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+var crashCmd = &cobra.Command{
+	Use:   "crash",
+	Short: "Inspect and manage crashes found while fuzzing",
+}
+
+var crashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List crash buckets",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		store, err := openCrashStore(cfg)
+		if err != nil {
+			return err
+		}
+
+		buckets, err := store.Buckets()
+		if err != nil {
+			return err
+		}
+
+		buckets, err = filterBuckets(cfg, buckets)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTARGET\tSTATUS\tCOUNT\tFIRST SEEN\tLAST SEEN\tMESSAGE")
+
+		for _, b := range buckets {
+			fmt.Fprintf(w, "%s\t%s.%s\t%s\t%d\t%s\t%s\t%s\n",
+				b.ID, b.Package, b.Name, b.Status, b.Count,
+				b.FirstSeen.Format(time.DateTime), b.LastSeen.Format(time.DateTime),
+				truncate(b.Message, 60))
+		}
+
+		return w.Flush()
+	},
+}
+
+var crashShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the details of a crash",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		store, err := openCrashStore(cfg)
+		if err != nil {
+			return err
+		}
+
+		b, err := store.Bucket(args[0])
+		if err != nil {
+			return err
+		}
+
+		crashes, err := store.Crashes(b)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("ID:         %s\n", b.ID)
+		fmt.Printf("Target:     %s.%s\n", b.Package, b.Name)
+		fmt.Printf("Status:     %s\n", b.Status)
+		fmt.Printf("First seen: %s\n", b.FirstSeen.Format(time.RFC3339))
+		fmt.Printf("Last seen:  %s\n", b.LastSeen.Format(time.RFC3339))
+		fmt.Printf("Count:      %d\n", b.Count)
+		fmt.Printf("Message:    %s\n", b.Message)
+
+		fmt.Println("\nSignature frames:")
+		for _, frame := range b.Frames {
+			fmt.Printf("  %s\n", frame)
+		}
+
+		for _, c := range crashes {
+			fmt.Printf("\nInput %s (%s, found %s):\n", c.Hash, c.InputPath, c.FoundAt.Format(time.RFC3339))
+
+			data, err := os.ReadFile(c.InputPath)
+			if err != nil {
+				fmt.Printf("  unavailable: %v\n", err)
+				continue
+			}
+			printInput(data)
+		}
+
+		if len(crashes) > 0 {
+			fmt.Println("\nStack:")
+			fmt.Println(crashes[len(crashes)-1].Output)
+		}
+
+		return nil
+	},
+}
+
+var crashReproduceCmd = &cobra.Command{
+	Use:   "reproduce <id>",
+	Short: "Check whether a crash still reproduces",
+	Args:  cobra.ExactArgs(1),
+	// A reproducing crash is reported through the exit status, not misuse
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		store, err := openCrashStore(cfg)
+		if err != nil {
+			return err
+		}
+
+		b, err := store.Bucket(args[0])
+		if err != nil {
+			return err
+		}
+
+		crashes, err := store.Crashes(b)
+		if err != nil {
+			return err
+		}
+		if len(crashes) == 0 {
+			return fmt.Errorf("crash %s has no stored inputs", b.ID)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// The most recent input is the most likely to match the current code
+		c := crashes[len(crashes)-1]
+		fmt.Printf("Reproducing %s with input %s... ", b.ID, c.Hash)
+
+		failed, output, err := runner.Reproduce(ctx, cfg, c)
+		if err != nil {
+			fmt.Println("ERROR")
+			fmt.Print(output)
+			return err
+		}
+
+		if !failed {
+			fmt.Println("PASS (no longer fails)")
+			if verbose {
+				fmt.Print(output)
+			}
+			return nil
+		}
+
+		fmt.Println("FAIL (still fails)")
+		fmt.Print(output)
+		return fmt.Errorf("crash %s still reproduces", b.ID)
+	},
+}
+
+var crashDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Delete crashes and their stored inputs",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		store, err := openCrashStore(cfg)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range args {
+			b, err := store.Delete(id)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Deleted %s (%s.%s, %d inputs)\n", b.ID, b.Package, b.Name, len(b.Crashes))
+		}
+
+		return nil
+	},
+}
+
+var crashMarkFixedCmd = &cobra.Command{
+	Use:   "mark-fixed <id>...",
+	Short: "Mark crashes as fixed",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, nil)
+		if err != nil {
+			return err
+		}
+
+		store, err := openCrashStore(cfg)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range args {
+			b, err := store.MarkFixed(id)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Marked %s (%s.%s) as fixed\n", b.ID, b.Package, b.Name)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	crashCmd.AddCommand(crashListCmd)
+	crashCmd.AddCommand(crashShowCmd)
+	crashCmd.AddCommand(crashReproduceCmd)
+	crashCmd.AddCommand(crashDeleteCmd)
+	crashCmd.AddCommand(crashMarkFixedCmd)
+
+	crashCmd.PersistentFlags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
+	addCrashDirFlag(crashCmd.PersistentFlags())
+	crashListCmd.Flags().StringP("root-dir", "r", ".", "Root directory of the project")
+	crashListCmd.Flags().Bool("all-modules", false, "Without a go.work workspace, use every module below the root directory, not only its own")
+	crashListCmd.Flags().StringSlice("tags", nil, "Build tags to discover, build and run fuzz targets with (comma-separated)")
+	addFilterFlags(crashListCmd.Flags())
+	crashReproduceCmd.Flags().StringP("root-dir", "r", ".", "Root directory of the project")
+	crashReproduceCmd.Flags().Bool("all-modules", false, "Without a go.work workspace, use every module below the root directory, not only its own")
+	crashReproduceCmd.Flags().StringSlice("tags", nil, "Build tags to discover, build and run fuzz targets with (comma-separated)")
+	crashReproduceCmd.Flags().BoolP("verbose", "v", false, "Print go test output even when the crash no longer reproduces")
+}
+
+// openCrashStore opens the configured crash store
+func openCrashStore(cfg *config.Config) (*crash.Store, error) {
+	store, err := crash.NewStore(cfg.CrashPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crash store: %w", err)
+	}
+
+	return store, nil
+}
+
+// filterBuckets keeps the buckets of the targets selected the way
+// discoverTargets selects them, by the configured filters, //fuzz:skip
+// annotations and skipped targets. Buckets of targets that aren't found
+// anymore are kept, unless targets are selected by tags, which they no
+// longer have.
+func filterBuckets(cfg *config.Config, buckets []*crash.Bucket) ([]*crash.Bucket, error) {
+	filter, err := targetFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := findTargets(cfg)
+	if err != nil {
+		return nil, err
+	}
+	selectedTargets, err := selectTargets(cfg, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, t := range targets {
+		known[t.Package+"."+t.Name] = true
+	}
+	selected := make(map[string]bool)
+	for _, t := range selectedTargets {
+		selected[t.Package+"."+t.Name] = true
+	}
+
+	var filtered []*crash.Bucket
+	for _, b := range buckets {
+		name := b.Package + "." + b.Name
+		if !filter.MatchName(b.Package, b.Name) {
+			continue
+		}
+		if known[name] && !selected[name] {
+			continue
+		}
+		if !known[name] && filter.UsesTags() {
+			continue
+		}
+		filtered = append(filtered, b)
+	}
+
+	return filtered, nil
+}
+
+// printInput prints a fuzz input stored in the Go fuzz v1 encoding, one
+// argument per line. Byte slices are shown as a hex dump.
+func printInput(data []byte) {
+	values, err := codec.Unmarshal(data)
+	if err != nil {
+		fmt.Printf("  invalid corpus file: %v\n", err)
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+		return
+	}
+
+	for i, v := range values {
+		switch v := v.(type) {
+		case []byte:
+			fmt.Printf("  #%d []byte (%d bytes):\n", i+1, len(v))
+			for _, dumpLine := range strings.Split(strings.TrimRight(hex.Dump(v), "\n"), "\n") {
+				fmt.Printf("    %s\n", dumpLine)
+			}
+		case string:
+			fmt.Printf("  #%d string (%d bytes): %q\n", i+1, len(v), v)
+		case uint8:
+			fmt.Printf("  #%d byte: %d %q\n", i+1, v, v)
+		case int32:
+			fmt.Printf("  #%d rune: %d %q\n", i+1, v, v)
+		default:
+			fmt.Printf("  #%d %s: %v\n", i+1, codec.TypeName(v), v)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
@@ -15,29 +16,35 @@ var listCmd = &cobra.Command{
 	Use:   "list [packages]",
 	Short: "List all fuzz targets in specified packages",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		rootDir, _ := cmd.Flags().GetString("root-dir")
-
-		// Use provided packages or default
-		patterns := []string{"./..."}
-		if len(args) > 0 {
-			patterns = args
+		cfg, err := loadConfig(cmd, args)
+		if err != nil {
+			return err
 		}
 
-		// Discover targets
-		targets, err := target.DiscoverTargets(target.DiscoveryOptions{
-			RootDir:  rootDir,
-			Patterns: patterns,
-		})
+		targets, err := discoverTargets(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to discover targets: %w", err)
+			return err
 		}
 
-		// Print targets
+		if long, _ := cmd.Flags().GetBool("long"); long {
+			printTargetsLong(targets, cfg.ChangedOnly)
+			return nil
+		}
+
+		// Print targets, with the reason they were selected for changed-only
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "PACKAGE\tNAME\tFILE")
+		if cfg.ChangedOnly {
+			fmt.Fprintln(w, "PACKAGE\tNAME\tFILE\tREASON")
+		} else {
+			fmt.Fprintln(w, "PACKAGE\tNAME\tFILE")
+		}
 
 		for _, t := range targets {
-			fmt.Fprintf(w, "%s\t%s\t%s\n", t.Package, t.Name, t.FilePath)
+			if cfg.ChangedOnly {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Package, t.Name, t.FilePath, t.ChangeReason)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", t.Package, t.Name, t.FilePath)
+			}
 		}
 
 		return w.Flush()
@@ -45,5 +52,65 @@ var listCmd = &cobra.Command{
 }
 
 func init() {
+	listCmd.Flags().BoolP("long", "l", false, "Show the fuzz argument signature and f.Add seeds of each target")
 	listCmd.Flags().StringP("root-dir", "r", ".", "Root directory of the project")
+	listCmd.Flags().Bool("all-modules", false, "Without a go.work workspace, use every module below the root directory, not only its own")
+	listCmd.Flags().StringSlice("tags", nil, "Build tags to discover, build and run fuzz targets with (comma-separated)")
+	addFilterFlags(listCmd.Flags())
+	listCmd.Flags().BoolP("changed-only", "d", false, "Only list targets affected by recent changes")
+	listCmd.Flags().String("git-ref", "HEAD~1", "Git reference to compare against for changes")
+	listCmd.Flags().String("impact", "package", "How changes select targets: package or function (call graph reachability)")
+}
+
+// printTargetsLong prints each target with its signature and seeds, and the
+// other targets it could share its corpus with
+func printTargetsLong(targets []*target.Target, changedOnly bool) {
+	bySignature := make(map[string][]*target.Target)
+	for _, t := range targets {
+		bySignature[t.Signature()] = append(bySignature[t.Signature()], t)
+	}
+
+	for i, t := range targets {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Printf("%s.%s\n", t.Package, t.Name)
+		if t.Module != "" {
+			fmt.Printf("  Module: %s (%s)\n", t.Module, t.ModuleDir)
+		}
+		fmt.Printf("  File: %s\n", t.FilePath)
+		if t.Description != "" {
+			fmt.Printf("  Description: %s\n", strings.TrimSpace(t.Description))
+		}
+		if changedOnly {
+			fmt.Printf("  Selected: %s\n", t.ChangeReason)
+		}
+		if len(t.Annotations.Tags) > 0 {
+			fmt.Printf("  Tags: %s\n", strings.Join(t.Annotations.Tags, ", "))
+		}
+		if t.Annotations.FuzzTime > 0 {
+			fmt.Printf("  Fuzz time: %s\n", t.Annotations.FuzzTime)
+		}
+
+		if t.Params == nil {
+			fmt.Println("  Signature: unknown (f.Fuzz not called directly)")
+		} else {
+			fmt.Printf("  Signature: %s\n", t.Signature())
+			for _, other := range bySignature[t.Signature()] {
+				if other != t {
+					fmt.Printf("  Same signature as: %s.%s\n", other.Package, other.Name)
+				}
+			}
+		}
+
+		fmt.Printf("  Seeds: %d\n", len(t.Seeds))
+		for _, seed := range t.Seeds {
+			kind := "static"
+			if !seed.Static {
+				kind = "dynamic"
+			}
+			fmt.Printf("    line %d (%s): %s\n", seed.Line, kind, strings.Join(seed.Values, ", "))
+		}
+	}
 }
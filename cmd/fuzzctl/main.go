@@ -28,4 +28,11 @@ func init() {
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(corpusCmd)
+	rootCmd.AddCommand(crashCmd)
+	rootCmd.AddCommand(regressCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(planCmd)
+
+	rootCmd.PersistentFlags().String("config", "", "Configuration file (YAML or JSON)")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Don't use cached discovery results or test binaries")
 }
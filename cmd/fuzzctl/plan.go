@@ -0,0 +1,80 @@
+// cmd/fuzzctl/plan.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/runner"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [packages]",
+	Short: "Show how much time each fuzz target will get",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		targets, err := discoverTargets(cfg)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TARGET\tDURATION\tRULE")
+
+		var total time.Duration
+		for _, entry := range runner.Plan(cfg, targets) {
+			rule := "no matching allocation"
+			switch {
+			case entry.Annotated:
+				rule = "//fuzz:time annotation"
+			case entry.Override:
+				rule = "targets override"
+			case entry.Key != "":
+				rule = fmt.Sprintf("time_allocation[%s] = %g", entry.Key, entry.Weight)
+			}
+			if entry.Impact > 0 && !entry.Override {
+				rule += fmt.Sprintf(", x%.2f change impact", entry.Impact)
+			}
+
+			fmt.Fprintf(w, "%s.%s\t%s\t%s\n",
+				entry.Target.Package, entry.Target.Name, entry.Duration, rule)
+			total += entry.Duration
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nTotal fuzzing time: %s across %d targets\n", total, len(targets))
+		if cfg.Strategy == config.StrategyAdaptive {
+			fmt.Println("With the adaptive strategy, the time of each target is decided round by round while fuzzing")
+		} else if cfg.Budget > 0 {
+			fmt.Printf("Durations are estimated shares of a %s budget; time left by targets that stop early goes to the others\n", cfg.Budget)
+		}
+		return nil
+	},
+}
+
+func init() {
+	planCmd.Flags().StringP("root-dir", "r", ".", "Root directory of the project")
+	planCmd.Flags().Bool("all-modules", false, "Without a go.work workspace, use every module below the root directory, not only its own")
+	planCmd.Flags().StringSlice("tags", nil, "Build tags to discover, build and run fuzz targets with (comma-separated)")
+	addFilterFlags(planCmd.Flags())
+	planCmd.Flags().DurationP("time", "t", 5*time.Minute, "Fuzzing time per target")
+	planCmd.Flags().Duration("budget", 0, "Total time for the run, split across targets by time allocation (overrides --time)")
+	planCmd.Flags().String("strategy", "fixed", "Time allocation strategy: fixed or adaptive")
+	planCmd.Flags().BoolP("changed-only", "d", false, "Only plan targets affected by recent changes")
+	planCmd.Flags().String("git-ref", "HEAD~1", "Git reference to compare against for changes")
+	planCmd.Flags().String("impact", "package", "How changes select targets: package or function (call graph reachability)")
+	planCmd.Flags().IntP("parallel", "p", 4, "Number of parallel processes shared by all running targets")
+	planCmd.Flags().IntP("jobs", "j", 0, "Number of fuzz targets to run concurrently (0 = one per parallel process)")
+}
@@ -0,0 +1,85 @@
+// cmd/fuzzctl/regress.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/crash"
+	"github.com/OmBiradar/go-fuzz-runner/internal/runner"
+)
+
+var regressCmd = &cobra.Command{
+	Use:   "regress [packages]",
+	Short: "Replay stored corpus and crashers without fuzzing",
+	Long: `regress runs every fuzz target once with its managed corpus and known
+crashers added to the seed corpus, without fuzzing. It exits with a non-zero
+status if any stored input fails, which makes it suitable for gating CI.`,
+	// Failing inputs are reported through the exit status, not misuse
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		targets, err := discoverTargets(cfg)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		engine, err := runner.NewFuzzEngine(ctx, cfg, targets)
+		if err != nil {
+			return fmt.Errorf("failed to create fuzz engine: %w", err)
+		}
+
+		results, err := engine.ReplayAll(ctx)
+		if err != nil {
+			return fmt.Errorf("replay failed: %w", err)
+		}
+
+		failing := 0
+		for _, result := range results {
+			status := "PASS"
+			if len(result.Failing) > 0 {
+				status = "FAIL"
+			}
+			fmt.Printf("%s.%s: %s (%d inputs) in %s\n",
+				result.Target.Package, result.Target.Name, status, result.Inputs, result.Duration)
+
+			for _, input := range result.Failing {
+				fmt.Printf("  %s %s: %s\n", input.Kind, input.Name, input.Path)
+				message := crash.ParseFailure(input.Output).Message
+				if message == "" {
+					message = input.Output
+				}
+				fmt.Printf("    %s\n", truncate(message, 100))
+			}
+
+			failing += len(result.Failing)
+		}
+
+		if failing > 0 {
+			return fmt.Errorf("%d stored inputs fail", failing)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	regressCmd.Flags().StringP("root-dir", "r", ".", "Root directory of the project")
+	regressCmd.Flags().Bool("all-modules", false, "Without a go.work workspace, use every module below the root directory, not only its own")
+	regressCmd.Flags().StringSlice("tags", nil, "Build tags to discover, build and run fuzz targets with (comma-separated)")
+	addFilterFlags(regressCmd.Flags())
+	regressCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
+	addCrashDirFlag(regressCmd.Flags())
+	regressCmd.Flags().IntP("parallel", "p", 4, "Number of targets to replay concurrently")
+}
@@ -3,10 +3,15 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/OmBiradar/go-fuzz-runner/internal/crash"
 	"github.com/OmBiradar/go-fuzz-runner/internal/runner"
 	"github.com/OmBiradar/go-fuzz-runner/internal/target"
 	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
@@ -16,82 +21,174 @@ var runCmd = &cobra.Command{
 	Use:   "run [packages]",
 	Short: "Run fuzz tests in specified packages",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Get flags
-		rootDir, _ := cmd.Flags().GetString("root-dir")
-		fuzzTime, _ := cmd.Flags().GetDuration("time")
-		corpusDir, _ := cmd.Flags().GetString("corpus")
-		parallelism, _ := cmd.Flags().GetInt("parallel")
-		changedOnly, _ := cmd.Flags().GetBool("changed-only")
-		gitRef, _ := cmd.Flags().GetString("git-ref")
-
-		// Create configuration
-		cfg := config.Default()
-		cfg.RootDir = rootDir
-		cfg.FuzzTime = fuzzTime
-		cfg.CorpusDir = corpusDir
-		cfg.Parallelism = parallelism
-		cfg.ChangedOnly = changedOnly
-		cfg.GitRef = gitRef
-
-		// Use provided packages or default
-		if len(args) > 0 {
-			cfg.Packages = args
+		quiet, _ := cmd.Flags().GetBool("quiet")
+
+		cfg, err := loadConfig(cmd, args)
+		if err != nil {
+			return err
 		}
 
-		// Discover targets
-		targets, err := target.DiscoverTargets(target.DiscoveryOptions{
-			RootDir:     cfg.RootDir,
-			Patterns:    cfg.Packages,
-			ChangedOnly: cfg.ChangedOnly,
-			GitRef:      cfg.GitRef,
-		})
+		targets, err := discoverTargets(cfg)
 		if err != nil {
-			return fmt.Errorf("failed to discover targets: %w", err)
+			return err
 		}
 
 		fmt.Printf("Discovered %d fuzz targets\n", len(targets))
+		if cfg.ChangedOnly {
+			for _, t := range targets {
+				fmt.Printf("  %s.%s: %s\n", t.Package, t.Name, t.ChangeReason)
+			}
+		}
+		if cfg.Budget > 0 {
+			fmt.Printf("Time budget: %s\n", cfg.Budget)
+		}
+		if cfg.Strategy == config.StrategyAdaptive {
+			fmt.Printf("Adaptive allocation in rounds of %s\n", cfg.RoundTime)
+		}
+
+		// Stop gracefully on Ctrl-C or when the CI job is terminated
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
 		// Create and run engine
-		engine, err := runner.NewFuzzEngine(cfg, targets)
+		engine, err := runner.NewFuzzEngine(ctx, cfg, targets)
 		if err != nil {
 			return fmt.Errorf("failed to create fuzz engine: %w", err)
 		}
 
-		if err := engine.RunAll(); err != nil {
-			return fmt.Errorf("fuzzing failed: %w", err)
+		if !quiet {
+			engine.Progress = printProgress
 		}
 
-		// Print results
+		// Without a user cache directory, test binaries are built into a
+		// temporary directory
+		if cfg.BinaryCache {
+			if dir, err := os.UserCacheDir(); err == nil {
+				engine.BinaryCacheDir = filepath.Join(dir, "go-fuzz-runner", "binaries")
+			}
+		}
+
+		runErr := engine.RunAll(ctx)
+		if ctx.Err() != nil {
+			fmt.Println("\nInterrupted, stopped running targets")
+		}
+
+		// Print results, including partial ones after an interrupt
 		fmt.Println("\nFuzzing Results:")
 		fmt.Println("================")
+		fmt.Printf("Built test binaries in %s\n\n", engine.BuildDuration.Round(time.Millisecond))
 
 		for _, result := range engine.Results {
-			fmt.Printf("%s.%s: %s in %s\n",
+			fmt.Printf("%s.%s: %s in %s",
 				result.Target.Package,
 				result.Target.Name,
 				statusString(result.Success),
 				result.Duration)
+			if result.Interrupted {
+				fmt.Print(" (interrupted)")
+			}
+			fmt.Println()
 
 			if !result.Success {
-				fmt.Printf("  Error: %s\n", truncate(result.ErrorMessage, 100))
+				errorMessage := result.ErrorMessage
+				if result.Crash != nil {
+					errorMessage = result.Crash.Output
+				}
+				fmt.Printf("  Error: %s\n", truncate(errorMessage, 100))
 				fmt.Printf("  Crash inputs: %d\n", len(result.CrashInputs))
+				if result.Crash != nil {
+					fmt.Printf("  Crash: %s (%s)\n", result.Crash.Hash, result.Crash.InputPath)
+					printBucket(result.CrashBucket)
+				}
 			}
 
+			fmt.Printf("  Execs: %d (%.0f/sec)\n", result.Execs, result.ExecsPerSec)
+			fmt.Printf("  Coverage: %.0f (baseline %.0f)\n", result.Coverage, result.BaselineCoverage)
+
 			fmt.Printf("  New corpus items: %d\n", result.NewCorpusItems)
+			if result.BinaryCached {
+				fmt.Printf("  Build: %s (cached)\n", result.BuildDuration.Round(time.Millisecond))
+			} else {
+				fmt.Printf("  Build: %s\n", result.BuildDuration.Round(time.Millisecond))
+			}
+			if result.Rounds > 0 {
+				fmt.Printf("  Rounds: %d\n", result.Rounds)
+			}
+			if result.Allotted > 0 {
+				fmt.Printf("  Budget share: %s\n", result.Allotted.Round(time.Second))
+			}
+			fmt.Println()
+		}
+
+		if len(engine.Skipped) > 0 {
+			fmt.Printf("Skipped %d targets, no time was left for them:\n", len(engine.Skipped))
+			for _, t := range engine.Skipped {
+				fmt.Printf("  %s.%s\n", t.Package, t.Name)
+			}
 			fmt.Println()
 		}
 
+		reportPath, err := engine.WriteReport(cfg.ReportDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Report written to %s\n", reportPath)
+
+		if runErr != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("fuzzing interrupted: %w", runErr)
+			}
+			return fmt.Errorf("fuzzing failed: %w", runErr)
+		}
+
 		return nil
 	},
 }
 
 func init() {
 	runCmd.Flags().StringP("root-dir", "r", ".", "Root directory of the project")
+	runCmd.Flags().Bool("all-modules", false, "Without a go.work workspace, use every module below the root directory, not only its own")
+	runCmd.Flags().StringSlice("tags", nil, "Build tags to discover, build and run fuzz targets with (comma-separated)")
+	addFilterFlags(runCmd.Flags())
 	runCmd.Flags().DurationP("time", "t", 5*time.Minute, "Fuzzing time per target")
+	runCmd.Flags().Duration("budget", 0, "Total time for the run, split across targets by time allocation (overrides --time)")
+	runCmd.Flags().String("strategy", "fixed", "Time allocation strategy: fixed or adaptive")
+	runCmd.Flags().Duration("round-time", 30*time.Second, "Length of a round of the adaptive strategy")
 	runCmd.Flags().StringP("corpus", "c", "./fuzz-corpus", "Corpus directory")
-	runCmd.Flags().IntP("parallel", "p", 4, "Number of parallel processes")
+	addCrashDirFlag(runCmd.Flags())
+	runCmd.Flags().Bool("crash-lines", false, "Include line numbers in crash signatures")
+	runCmd.Flags().IntP("parallel", "p", 4, "Number of parallel processes shared by all running targets")
+	runCmd.Flags().IntP("jobs", "j", 0, "Number of fuzz targets to run concurrently (0 = one per parallel process)")
 	runCmd.Flags().BoolP("changed-only", "d", false, "Only fuzz targets affected by recent changes")
 	runCmd.Flags().String("git-ref", "HEAD~1", "Git reference to compare against for changes")
+	runCmd.Flags().String("impact", "package", "How changes select targets: package or function (call graph reachability)")
+	runCmd.Flags().String("report-dir", "./fuzz-reports", "Directory for output reports")
+	runCmd.Flags().BoolP("quiet", "q", false, "Don't print live fuzzing progress")
+}
+
+// printBucket prints whether a crash is new or a repeat of a known one
+func printBucket(b *crash.Bucket) {
+	if b.Count == 1 {
+		fmt.Printf("  Bucket: %s (new)\n", b.ID)
+		return
+	}
+	fmt.Printf("  Bucket: %s (seen %d times since %s)\n",
+		b.ID, b.Count, b.FirstSeen.Format(time.RFC3339))
+}
+
+// printProgress prints a live progress line for a running target
+func printProgress(t *target.Target, s runner.Sample) {
+	switch s.Phase {
+	case runner.PhaseBaseline:
+		fmt.Printf("[%s.%s] %s: baseline coverage %d/%d\n",
+			t.Package, t.Name, s.Elapsed, s.BaselineDone, s.BaselineTotal)
+	case runner.PhaseMinimizing:
+		fmt.Printf("[%s.%s] %s: minimizing failing input\n",
+			t.Package, t.Name, s.Elapsed)
+	default:
+		fmt.Printf("[%s.%s] %s: execs %d (%.0f/sec), new interesting %d, coverage %d\n",
+			t.Package, t.Name, s.Elapsed, s.Execs, s.ExecsPerSec, s.NewInteresting, s.CoverageBits)
+	}
 }
 
 func statusString(success bool) string {
@@ -1,9 +1,16 @@
 module github.com/OmBiradar/go-fuzz-runner
 
-go 1.24.2
+go 1.25.0
+
+require (
+	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+	golang.org/x/mod v0.37.0
+	golang.org/x/tools v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.9.1 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/sync v0.21.0 // indirect
 )
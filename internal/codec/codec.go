@@ -0,0 +1,356 @@
+// internal/codec/codec.go
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Header is the first line of a corpus file in the version 1 encoding
+const Header = "go test fuzz v1"
+
+// Marshal encodes the arguments of a fuzz input as a corpus file. Values are
+// written the way the go command writes them, so a marshaled input has the
+// same bytes, and thus the same hash, as the fuzzer's file for it.
+func Marshal(values []any) ([]byte, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values to marshal")
+	}
+
+	var b bytes.Buffer
+	b.WriteString(Header + "\n")
+	for i, v := range values {
+		line, err := Format(v)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return b.Bytes(), nil
+}
+
+// Format encodes a single argument as a line of a corpus file, e.g.
+// `int64(5)` or `[]byte("abc")`
+func Format(v any) (string, error) {
+	switch v := v.(type) {
+	case []byte:
+		return fmt.Sprintf("[]byte(%q)", v), nil
+	case string:
+		return fmt.Sprintf("string(%q)", v), nil
+	case bool, int, int8, int16, int64, uint, uint16, uint32, uint64:
+		return fmt.Sprintf("%T(%v)", v, v), nil
+	case byte:
+		// Every byte has a character literal
+		return fmt.Sprintf("byte(%q)", v), nil
+	case rune:
+		// Negative values, surrogate halves and values beyond the last code
+		// point have no character literal
+		if utf8.ValidRune(v) {
+			return fmt.Sprintf("rune(%q)", v), nil
+		}
+		return fmt.Sprintf("int32(%d)", v), nil
+	case float32:
+		// NaNs other than the usual one keep their exact bits
+		if math.IsNaN(float64(v)) && math.Float32bits(v) != math.Float32bits(float32(math.NaN())) {
+			return fmt.Sprintf("math.Float32frombits(0x%x)", math.Float32bits(v)), nil
+		}
+		return fmt.Sprintf("float32(%v)", v), nil
+	case float64:
+		if math.IsNaN(v) && math.Float64bits(v) != math.Float64bits(math.NaN()) {
+			return fmt.Sprintf("math.Float64frombits(0x%x)", math.Float64bits(v)), nil
+		}
+		return fmt.Sprintf("float64(%v)", v), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %T", v)
+}
+
+// Unmarshal decodes a corpus file into the arguments of a fuzz input. It
+// accepts everything the go command accepts, e.g. `rune(120)` as well as
+// `rune('x')`. Values have the Go types of their arguments, so byte and
+// uint8 values are both uint8, and rune and int32 values both int32.
+func Unmarshal(data []byte) ([]any, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty corpus file")
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if version := strings.TrimSuffix(lines[0], "\r"); version != Header {
+		return nil, fmt.Errorf("unknown encoding version %q, want %q", version, Header)
+	}
+
+	var values []any
+	for i, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		v, err := Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %q: %w", i+2, line, err)
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no values in corpus file")
+	}
+
+	return values, nil
+}
+
+// Parse decodes a single line of a corpus file
+func Parse(line string) (any, error) {
+	expr, err := parser.ParseExpr(line)
+	if err != nil {
+		return nil, err
+	}
+
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || call.Ellipsis.IsValid() {
+		return nil, fmt.Errorf("want a conversion with one argument such as int(5)")
+	}
+	arg := call.Args[0]
+
+	switch fun := call.Fun.(type) {
+	case *ast.ArrayType:
+		if elt, ok := fun.Elt.(*ast.Ident); !ok || fun.Len != nil || elt.Name != "byte" {
+			return nil, fmt.Errorf("want []byte or a basic type")
+		}
+		s, err := stringLiteral(arg)
+		if err != nil {
+			return nil, fmt.Errorf("[]byte: %w", err)
+		}
+		return []byte(s), nil
+
+	case *ast.SelectorExpr:
+		pkg, ok := fun.X.(*ast.Ident)
+		if !ok || pkg.Name != "math" || (fun.Sel.Name != "Float32frombits" && fun.Sel.Name != "Float64frombits") {
+			return nil, fmt.Errorf("want math.Float32frombits or math.Float64frombits")
+		}
+		lit, ok := arg.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, fmt.Errorf("math.%s: want an integer literal", fun.Sel.Name)
+		}
+		if fun.Sel.Name == "Float32frombits" {
+			bits, err := strconv.ParseUint(lit.Value, 0, 32)
+			return math.Float32frombits(uint32(bits)), err
+		}
+		bits, err := strconv.ParseUint(lit.Value, 0, 64)
+		return math.Float64frombits(bits), err
+
+	case *ast.Ident:
+		return parseBasic(fun.Name, arg)
+	}
+
+	return nil, fmt.Errorf("want []byte or a basic type")
+}
+
+// parseBasic decodes the literal of a value of a basic type
+func parseBasic(typ string, arg ast.Expr) (any, error) {
+	switch typ {
+	case "string":
+		s, err := stringLiteral(arg)
+		if err != nil {
+			return nil, fmt.Errorf("string: %w", err)
+		}
+		return s, nil
+
+	case "bool":
+		if id, ok := arg.(*ast.Ident); ok && (id.Name == "true" || id.Name == "false") {
+			return id.Name == "true", nil
+		}
+		return nil, fmt.Errorf("bool: want true or false")
+	}
+
+	value, kind, err := numberLiteral(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typ, err)
+	}
+
+	switch typ {
+	case "byte", "rune":
+		if kind == token.CHAR {
+			return charValue(typ, value)
+		}
+		if kind != token.INT {
+			return nil, fmt.Errorf("%s: want a character or integer literal", typ)
+		}
+		if typ == "byte" {
+			return parseUint(value, "uint8")
+		}
+		return parseInt(value, "int32")
+
+	case "int", "int8", "int16", "int32", "int64":
+		if kind != token.INT {
+			return nil, fmt.Errorf("%s: want an integer literal", typ)
+		}
+		return parseInt(value, typ)
+
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		if kind != token.INT {
+			return nil, fmt.Errorf("%s: want an integer literal", typ)
+		}
+		return parseUint(value, typ)
+
+	case "float32", "float64":
+		if kind != token.FLOAT && kind != token.INT {
+			return nil, fmt.Errorf("%s: want a floating-point or integer literal", typ)
+		}
+		if typ == "float32" {
+			f, err := strconv.ParseFloat(value, 32)
+			return float32(f), err
+		}
+		return strconv.ParseFloat(value, 64)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+// stringLiteral returns the value of a string literal
+func stringLiteral(arg ast.Expr) (string, error) {
+	lit, ok := arg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", fmt.Errorf("want a string literal")
+	}
+	return strconv.Unquote(lit.Value)
+}
+
+// numberLiteral returns the text and kind of a numeric or character literal,
+// which may be negated. Infinities and NaN are written as +Inf, -Inf and NaN.
+func numberLiteral(arg ast.Expr) (string, token.Token, error) {
+	sign := ""
+	if op, ok := arg.(*ast.UnaryExpr); ok {
+		if op.Op != token.SUB && op.Op != token.ADD {
+			return "", 0, fmt.Errorf("unsupported operator %s", op.Op)
+		}
+		sign, arg = op.Op.String(), op.X
+	}
+
+	switch lit := arg.(type) {
+	case *ast.BasicLit:
+		if sign == "+" || lit.Kind == token.STRING || (sign != "" && lit.Kind == token.CHAR) {
+			return "", 0, fmt.Errorf("want a number")
+		}
+		return sign + lit.Value, lit.Kind, nil
+
+	case *ast.Ident:
+		switch {
+		case lit.Name == "Inf" && sign != "":
+			return sign + "Inf", token.FLOAT, nil
+		case lit.Name == "NaN" && sign == "":
+			return "NaN", token.FLOAT, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("want a literal")
+}
+
+// charValue decodes a character literal as a byte or rune
+func charValue(typ, literal string) (any, error) {
+	if len(literal) < 2 {
+		return nil, fmt.Errorf("%s: malformed character literal", typ)
+	}
+	r, _, _, err := strconv.UnquoteChar(literal[1:len(literal)-1], '\'')
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", typ, err)
+	}
+
+	if typ == "rune" {
+		return r, nil
+	}
+	if r > math.MaxUint8 {
+		return nil, fmt.Errorf("byte: %s doesn't fit into a byte", literal)
+	}
+	return byte(r), nil
+}
+
+// parseInt parses a signed integer literal of the given type. Values of int
+// are parsed as 64 bits and wrapped, like the go command does on 32-bit
+// platforms.
+func parseInt(literal, typ string) (any, error) {
+	bits := map[string]int{"int": 64, "int8": 8, "int16": 16, "int32": 32, "int64": 64}[typ]
+	n, err := strconv.ParseInt(literal, 0, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "int":
+		return int(n), nil
+	case "int8":
+		return int8(n), nil
+	case "int16":
+		return int16(n), nil
+	case "int32":
+		return int32(n), nil
+	}
+	return n, nil
+}
+
+// parseUint parses an unsigned integer literal of the given type
+func parseUint(literal, typ string) (any, error) {
+	bits := map[string]int{"uint": 64, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64}[typ]
+	n, err := strconv.ParseUint(literal, 0, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "uint":
+		return uint(n), nil
+	case "uint8":
+		return uint8(n), nil
+	case "uint16":
+		return uint16(n), nil
+	case "uint32":
+		return uint32(n), nil
+	}
+	return n, nil
+}
+
+// TypeName returns the type of a decoded value as written in Go, e.g.
+// "[]byte" or "int64". Bytes are "uint8" and runes "int32".
+func TypeName(v any) string {
+	if _, ok := v.([]byte); ok {
+		return "[]byte"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Check reports whether decoded values fit the types of a fuzz function's
+// arguments, e.g. "[]byte" and "int"
+func Check(values []any, params []string) error {
+	if len(values) != len(params) {
+		return fmt.Errorf("%d values, want %d (%s)", len(values), len(params), strings.Join(params, ", "))
+	}
+
+	for i, v := range values {
+		if got, want := TypeName(v), canonicalType(params[i]); got != want {
+			return fmt.Errorf("argument %d is %s, want %s", i+1, got, params[i])
+		}
+	}
+
+	return nil
+}
+
+// canonicalType resolves the aliases byte and rune in a type name
+func canonicalType(typ string) string {
+	switch typ {
+	case "byte":
+		return "uint8"
+	case "rune":
+		return "int32"
+	case "[]uint8":
+		return "[]byte"
+	}
+	return typ
+}
@@ -0,0 +1,215 @@
+// internal/codec/codec_test.go
+package codec
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// same reports whether two decoded values have the same type and value,
+// comparing floats by their bits so NaNs and -0 are told apart
+func same(a, b any) bool {
+	switch a := a.(type) {
+	case float32:
+		b, ok := b.(float32)
+		return ok && math.Float32bits(a) == math.Float32bits(b)
+	case float64:
+		b, ok := b.(float64)
+		return ok && math.Float64bits(a) == math.Float64bits(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// The files were written by the go command's own encoder
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		file  string
+	}{
+		{"float32 NaN bits", math.Float32frombits(0x7fc00001), "go test fuzz v1\nmath.Float32frombits(0x7fc00001)\n"},
+		{"float32 negative NaN", math.Float32frombits(0xffc00000), "go test fuzz v1\nmath.Float32frombits(0xffc00000)\n"},
+		{"float64 NaN bits", math.Float64frombits(0x7ff8000000000002), "go test fuzz v1\nmath.Float64frombits(0x7ff8000000000002)\n"},
+		{"float64 negative NaN", math.Float64frombits(0xfff8000000000001), "go test fuzz v1\nmath.Float64frombits(0xfff8000000000001)\n"},
+		{"float32 usual NaN", float32(math.NaN()), "go test fuzz v1\nfloat32(NaN)\n"},
+		{"float64 usual NaN", math.NaN(), "go test fuzz v1\nfloat64(NaN)\n"},
+		{"float64 +Inf", math.Inf(1), "go test fuzz v1\nfloat64(+Inf)\n"},
+		{"float64 -Inf", math.Inf(-1), "go test fuzz v1\nfloat64(-Inf)\n"},
+		{"float32 -Inf", float32(math.Inf(-1)), "go test fuzz v1\nfloat32(-Inf)\n"},
+		{"float64 -0", math.Copysign(0, -1), "go test fuzz v1\nfloat64(-0)\n"},
+		{"float32 -0", float32(math.Copysign(0, -1)), "go test fuzz v1\nfloat32(-0)\n"},
+		{"float64", 1.5, "go test fuzz v1\nfloat64(1.5)\n"},
+		{"float64 whole", float64(3), "go test fuzz v1\nfloat64(3)\n"},
+		{"float64 exponent", 1e21, "go test fuzz v1\nfloat64(1e+21)\n"},
+		{"float32", float32(0.1), "go test fuzz v1\nfloat32(0.1)\n"},
+		{"negative rune", int32(-1), "go test fuzz v1\nint32(-1)\n"},
+		{"smallest rune", int32(math.MinInt32), "go test fuzz v1\nint32(-2147483648)\n"},
+		{"surrogate half", rune(0xd800), "go test fuzz v1\nint32(55296)\n"},
+		{"beyond the last code point", rune(0x110000), "go test fuzz v1\nint32(1114112)\n"},
+		{"rune", 'x', "go test fuzz v1\nrune('x')\n"},
+		{"rune escape", '\n', "go test fuzz v1\nrune('\\n')\n"},
+		{"rune control", rune(0x7f), "go test fuzz v1\nrune('\\x7f')\n"},
+		{"rune outside the BMP", rune(0x1f600), "go test fuzz v1\nrune('😀')\n"},
+		{"byte", byte('x'), "go test fuzz v1\nbyte('x')\n"},
+		{"byte zero", byte(0), "go test fuzz v1\nbyte('\\x00')\n"},
+		{"byte quote", byte('\''), "go test fuzz v1\nbyte('\\'')\n"},
+		{"byte above ASCII", byte(0x80), "go test fuzz v1\nbyte('\\u0080')\n"},
+		{"byte max", byte(0xff), "go test fuzz v1\nbyte('ÿ')\n"},
+		{"int", -5, "go test fuzz v1\nint(-5)\n"},
+		{"int8", int8(-128), "go test fuzz v1\nint8(-128)\n"},
+		{"int16", int16(-3), "go test fuzz v1\nint16(-3)\n"},
+		{"int64", int64(42), "go test fuzz v1\nint64(42)\n"},
+		{"uint", uint(7), "go test fuzz v1\nuint(7)\n"},
+		{"uint16", uint16(9), "go test fuzz v1\nuint16(9)\n"},
+		{"uint64", uint64(math.MaxUint64), "go test fuzz v1\nuint64(18446744073709551615)\n"},
+		{"bool", true, "go test fuzz v1\nbool(true)\n"},
+		{"string", "a\"\n\x00é", "go test fuzz v1\nstring(\"a\\\"\\n\\x00é\")\n"},
+		{"bytes", []byte("\x00\xff"), "go test fuzz v1\n[]byte(\"\\x00\\xff\")\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal([]any{tt.value})
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tt.file {
+				t.Errorf("Marshal = %q, want %q", data, tt.file)
+			}
+
+			values, err := Unmarshal([]byte(tt.file))
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if len(values) != 1 || !same(values[0], tt.value) {
+				t.Errorf("Unmarshal = %#v, want %#v", values, tt.value)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		values []any
+	}{
+		{"rune as integer", "go test fuzz v1\nrune(120)\n", []any{int32('x')}},
+		{"negative rune as integer", "go test fuzz v1\nrune(-1)\n", []any{int32(-1)}},
+		{"byte as integer", "go test fuzz v1\nbyte(120)\n", []any{byte('x')}},
+		{"byte as hex", "go test fuzz v1\nbyte(0xff)\n", []any{byte(0xff)}},
+		{"uint8", "go test fuzz v1\nuint8(5)\n", []any{byte(5)}},
+		{"int32", "go test fuzz v1\nint32(5)\n", []any{int32(5)}},
+		{"float from integer", "go test fuzz v1\nfloat64(3)\n", []any{float64(3)}},
+		{"float32 +Inf", "go test fuzz v1\nfloat32(+Inf)\n", []any{float32(math.Inf(1))}},
+		{"CRLF", "go test fuzz v1\r\nint(5)\r\nstring(\"a\")\r\n", []any{5, "a"}},
+		{"no final newline", "go test fuzz v1\nint(5)", []any{5}},
+		{"blank lines", "go test fuzz v1\n\nint(5)\n\n", []any{5}},
+		{"several values", "go test fuzz v1\n[]byte(\"abc\")\nint(5)\nstring(\"x\")\n", []any{[]byte("abc"), 5, "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := Unmarshal([]byte(tt.file))
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if len(values) != len(tt.values) {
+				t.Fatalf("Unmarshal = %#v, want %#v", values, tt.values)
+			}
+			for i := range values {
+				if !same(values[i], tt.values[i]) {
+					t.Errorf("value %d = %#v, want %#v", i, values[i], tt.values[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		err  string
+	}{
+		{"empty", "", "empty corpus file"},
+		{"wrong version", "go test fuzz v2\nint(5)\n", "unknown encoding version"},
+		{"no values", "go test fuzz v1\n", "no values"},
+		{"byte out of range", "go test fuzz v1\nbyte(256)\n", "line 2"},
+		{"byte character out of range", "go test fuzz v1\nbyte('€')\n", "doesn't fit into a byte"},
+		{"negative character", "go test fuzz v1\nrune(-'x')\n", "want a number"},
+		{"int8 out of range", "go test fuzz v1\nint8(128)\n", "out of range"},
+		{"float for an integer", "go test fuzz v1\nint(1.5)\n", "want an integer literal"},
+		{"negative NaN", "go test fuzz v1\nfloat64(-NaN)\n", "want a literal"},
+		{"Inf without sign", "go test fuzz v1\nfloat64(Inf)\n", "want a literal"},
+		{"two arguments", "go test fuzz v1\nint(1, 2)\n", "one argument"},
+		{"other package", "go test fuzz v1\nstrconv.Itoa(5)\n", "math.Float32frombits"},
+		{"array type", "go test fuzz v1\n[]int(\"a\")\n", "want []byte"},
+		{"unknown type", "go test fuzz v1\ncomplex64(1)\n", "unsupported type"},
+		{"not a conversion", "go test fuzz v1\n5\n", "want a conversion"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Unmarshal error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// A fuzzer's file marshals back to its own bytes, so its hash doesn't change
+func TestMarshalUnmarshal(t *testing.T) {
+	file := []byte("go test fuzz v1\n" +
+		"[]byte(\"\\x00\\xffabc\")\n" +
+		"string(\"a\\\"\\n\\x00é\")\n" +
+		"int(-5)\n" +
+		"int32(-1)\n" +
+		"rune('x')\n" +
+		"byte('\\x00')\n" +
+		"uint64(18446744073709551615)\n" +
+		"float64(-0)\n" +
+		"float64(+Inf)\n" +
+		"math.Float64frombits(0x7ff8000000000002)\n" +
+		"math.Float32frombits(0x7fc00001)\n" +
+		"bool(false)\n")
+
+	values, err := Unmarshal(file)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	data, err := Marshal(values)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !bytes.Equal(data, file) {
+		t.Errorf("Marshal(Unmarshal(f)) = %q, want %q", data, file)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []any
+		params []string
+		ok     bool
+	}{
+		{"same types", []any{[]byte("a"), 5}, []string{"[]byte", "int"}, true},
+		{"byte alias", []any{byte(1), []byte("a")}, []string{"byte", "[]uint8"}, true},
+		{"rune alias", []any{'x'}, []string{"rune"}, true},
+		{"wrong type", []any{int64(5)}, []string{"int"}, false},
+		{"too few values", []any{5}, []string{"int", "string"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(tt.values, tt.params); (err == nil) != tt.ok {
+				t.Errorf("Check = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
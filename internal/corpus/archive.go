@@ -0,0 +1,523 @@
+// internal/corpus/archive.go
+package corpus
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/codec"
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+const (
+	// manifestName is the file at the root of an archive describing it
+	manifestName = "manifest.json"
+
+	// manifestVersion changes whenever the manifest format changes
+	manifestVersion = 1
+)
+
+// Encodings of the entries in an archive
+const (
+	// EncodingV1 entries are corpus files as the go command writes them
+	EncodingV1 = "v1"
+
+	// EncodingRaw entries are the bytes of the single argument of their
+	// target, as other fuzzers store inputs
+	EncodingRaw = "raw"
+)
+
+// Manifest describes the targets and entries of an exported corpus
+type Manifest struct {
+	Version   int              `json:"version"`
+	CreatedAt time.Time        `json:"created_at"`
+	Targets   []*ArchiveTarget `json:"targets"`
+}
+
+// ArchiveTarget describes the entries of a target in an exported corpus.
+// Each entry is stored as <Dir>/<sha256>, hashed in the v1 encoding even if
+// it is stored raw, so it keeps its name when it is imported again. Raw
+// entries are hashed as codec.Marshal encodes them, which is how the go
+// command writes its own files.
+type ArchiveTarget struct {
+	Target    string   `json:"target"`
+	Module    string   `json:"module,omitempty"`
+	Package   string   `json:"package"`
+	Name      string   `json:"name"`
+	Signature string   `json:"signature,omitempty"`
+	Params    []string `json:"params,omitempty"`
+	Dir       string   `json:"dir"`
+	Encoding  string   `json:"encoding"`
+	Entries   []*Entry `json:"entries"`
+}
+
+// FuzzTarget returns the target whose entries these are
+func (at *ArchiveTarget) FuzzTarget() *target.Target {
+	return &target.Target{
+		Name:    at.Name,
+		Package: at.Package,
+		Module:  at.Module,
+		Params:  at.Params,
+	}
+}
+
+// Archive is an exported corpus, read into memory
+type Archive struct {
+	Manifest *Manifest
+	files    map[string][]byte
+}
+
+// Export writes the corpora of targets to path, a .tar.gz, .tgz or .zip
+// archive or else a directory, with a manifest of the entries. With raw set,
+// the entries of targets taking a single []byte or string are written as
+// that argument's bytes; entries that don't decode to it are left out and
+// counted. Targets without entries are left out as well.
+func (m *CorpusManager) Export(path string, targets []*target.Target, raw bool) (*Manifest, int, error) {
+	w, err := createArchive(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	manifest, skipped, err := m.export(w, targets, raw)
+	if closeErr := w.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write archive: %w", closeErr)
+	}
+	if err != nil {
+		// A directory may have held other files, so only archives go
+		if archiveFormat(path) != "" {
+			os.Remove(path)
+		}
+		return nil, 0, err
+	}
+
+	return manifest, skipped, nil
+}
+
+// export writes the entries of targets and the manifest to an archive
+func (m *CorpusManager) export(w archiveWriter, targets []*target.Target, raw bool) (*Manifest, int, error) {
+	manifest := &Manifest{
+		Version:   manifestVersion,
+		CreatedAt: time.Now().UTC(),
+		Targets:   []*ArchiveTarget{},
+	}
+
+	skipped := 0
+	for _, t := range targets {
+		idx, err := m.Index(t)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(idx.Entries) == 0 {
+			continue
+		}
+
+		at := &ArchiveTarget{
+			Target:    t.Package + "." + t.Name,
+			Module:    t.Module,
+			Package:   t.Package,
+			Name:      t.Name,
+			Signature: t.Signature(),
+			Params:    t.Params,
+			Dir:       path.Join(escapePath(t.Module), escapePath(t.Package), t.Name),
+			Encoding:  EncodingV1,
+		}
+		if t.Params == nil {
+			at.Signature = ""
+		}
+		if raw && isRawTarget(t.Params) {
+			at.Encoding = EncodingRaw
+		}
+
+		dir := m.GetTargetDir(t)
+		seen := make(map[string]bool)
+		for _, entry := range idx.Entries {
+			data, err := os.ReadFile(filepath.Join(dir, entry.Hash))
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to read corpus entry: %w", err)
+			}
+
+			if at.Encoding == EncodingRaw {
+				values, err := codec.Unmarshal(data)
+				if err == nil {
+					err = codec.Check(values, t.Params)
+				}
+				if err != nil {
+					skipped++
+					continue
+				}
+				data = rawBytes(values[0])
+
+				// Files written by hand, e.g. with rune(120) or CRLF line
+				// endings, are named after a different encoding than the one
+				// the raw bytes are turned back into
+				encoded, err := codec.Marshal(values)
+				if err != nil {
+					return nil, 0, err
+				}
+				if hash := hashData(encoded); hash != entry.Hash {
+					canonical := *entry
+					canonical.Hash = hash
+					canonical.Size = int64(len(encoded))
+					entry = &canonical
+				}
+			}
+			if seen[entry.Hash] {
+				continue
+			}
+			seen[entry.Hash] = true
+
+			if err := w.add(path.Join(at.Dir, entry.Hash), data, entry.AddedAt); err != nil {
+				return nil, 0, fmt.Errorf("failed to write archive: %w", err)
+			}
+			at.Entries = append(at.Entries, entry)
+		}
+
+		if len(at.Entries) > 0 {
+			manifest.Targets = append(manifest.Targets, at)
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := w.add(manifestName, data, manifest.CreatedAt); err != nil {
+		return nil, 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return manifest, skipped, nil
+}
+
+// OpenArchive reads an archive or directory written by Export
+func OpenArchive(path string) (*Archive, error) {
+	files, err := readArchive(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s, it wasn't written by corpus export", path, manifestName)
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s: %w", path, err)
+	}
+	if manifest.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d in %s", manifest.Version, path)
+	}
+	for _, at := range manifest.Targets {
+		if err := at.validate(); err != nil {
+			return nil, fmt.Errorf("invalid manifest in %s: %w", path, err)
+		}
+	}
+
+	return &Archive{Manifest: manifest, files: files}, nil
+}
+
+// validate checks that a target read from a manifest names a corpus
+// directory inside the corpus and that its entries are hashes, so a crafted
+// archive can't write elsewhere
+func (at *ArchiveTarget) validate() error {
+	if !token.IsIdentifier(at.Name) || !strings.HasPrefix(at.Name, "Fuzz") {
+		return fmt.Errorf("invalid target name %q", at.Name)
+	}
+	if !validImportPath(at.Package) || (at.Module != "" && !validImportPath(at.Module)) {
+		return fmt.Errorf("invalid package of %s", at.Name)
+	}
+
+	switch at.Encoding {
+	case EncodingV1:
+	case EncodingRaw:
+		if !isRawTarget(at.Params) {
+			return fmt.Errorf("%s.%s has raw entries but doesn't take a single []byte or string", at.Package, at.Name)
+		}
+	default:
+		return fmt.Errorf("unknown encoding %q of %s.%s", at.Encoding, at.Package, at.Name)
+	}
+
+	for _, e := range at.Entries {
+		if !isHash(e.Hash) {
+			return fmt.Errorf("invalid entry %q of %s.%s", e.Hash, at.Package, at.Name)
+		}
+	}
+
+	return nil
+}
+
+// Merge adds the entries of a target in an archive to its corpus, keeping
+// when and how they were found, and returns how many of them it didn't have
+// yet. Merging the same archive again adds nothing. If an entry is missing
+// or doesn't match its hash, nothing is added.
+func (m *CorpusManager) Merge(a *Archive, at *ArchiveTarget) (int, error) {
+	entries := make([]*Entry, 0, len(at.Entries))
+	inputs := make([][]byte, 0, len(at.Entries))
+	for _, e := range at.Entries {
+		data, ok := a.files[path.Join(at.Dir, e.Hash)]
+		if !ok {
+			return 0, fmt.Errorf("entry %s of %s.%s is missing from the archive", e.Hash, at.Package, at.Name)
+		}
+
+		if at.Encoding == EncodingRaw {
+			var value any = data
+			if at.Params[0] == "string" {
+				value = string(data)
+			}
+			encoded, err := codec.Marshal([]any{value})
+			if err != nil {
+				return 0, err
+			}
+			data = encoded
+		}
+		if hashData(data) != e.Hash {
+			return 0, fmt.Errorf("entry %s of %s.%s doesn't match its hash", e.Hash, at.Package, at.Name)
+		}
+
+		entry := *e
+		entry.Size = int64(len(data))
+		entries = append(entries, &entry)
+		inputs = append(inputs, data)
+	}
+
+	added, err := m.addEntries(at.FuzzTarget(), entries, inputs)
+	if err != nil {
+		return added, fmt.Errorf("failed to store corpus entries: %w", err)
+	}
+
+	return added, nil
+}
+
+// isRawTarget reports whether the inputs of a target can be stored raw
+func isRawTarget(params []string) bool {
+	return len(params) == 1 && isVariable(params[0])
+}
+
+// rawBytes returns the bytes of a decoded []byte or string value
+func rawBytes(v any) []byte {
+	if s, ok := v.(string); ok {
+		return []byte(s)
+	}
+	return v.([]byte)
+}
+
+// validImportPath reports whether an import path read from an archive is
+// safe to turn into a corpus directory
+func validImportPath(p string) bool {
+	if p == "" || strings.Contains(p, "\\") {
+		return false
+	}
+	for _, elem := range strings.Split(p, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// archiveFormat returns the format of an archive from its file name, or ""
+// for a directory
+func archiveFormat(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(path, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// archiveWriter adds files to an archive
+type archiveWriter interface {
+	add(name string, data []byte, modTime time.Time) error
+	Close() error
+}
+
+// createArchive creates the archive or directory at path
+func createArchive(path string) (archiveWriter, error) {
+	format := archiveFormat(path)
+	if format == "" {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create export directory: %w", err)
+		}
+		return dirArchive(path), nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	if format == "zip" {
+		return &zipArchive{f: f, w: zip.NewWriter(f)}, nil
+	}
+	gz := gzip.NewWriter(f)
+	return &tarArchive{f: f, gz: gz, w: tar.NewWriter(gz)}, nil
+}
+
+// tarArchive writes a gzip-compressed tar archive
+type tarArchive struct {
+	f  *os.File
+	gz *gzip.Writer
+	w  *tar.Writer
+}
+
+func (a *tarArchive) add(name string, data []byte, modTime time.Time) error {
+	err := a.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.w.Write(data)
+	return err
+}
+
+func (a *tarArchive) Close() error {
+	return errors.Join(a.w.Close(), a.gz.Close(), a.f.Close())
+}
+
+// zipArchive writes a zip archive
+type zipArchive struct {
+	f *os.File
+	w *zip.Writer
+}
+
+func (a *zipArchive) add(name string, data []byte, modTime time.Time) error {
+	w, err := a.w.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (a *zipArchive) Close() error {
+	return errors.Join(a.w.Close(), a.f.Close())
+}
+
+// dirArchive writes the files of an archive into a directory
+type dirArchive string
+
+func (a dirArchive) add(name string, data []byte, modTime time.Time) error {
+	path := filepath.Join(string(a), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	return os.Chtimes(path, modTime, modTime)
+}
+
+func (a dirArchive) Close() error {
+	return nil
+}
+
+// readArchive returns the regular files of the archive or directory at
+// path by their slash-separated names
+func readArchive(p string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	switch archiveFormat(p) {
+	case "tar.gz":
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer f.Close()
+
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive %s: %w", p, err)
+		}
+		r := tar.NewReader(gz)
+		for {
+			hdr, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read archive %s: %w", p, err)
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read archive %s: %w", p, err)
+			}
+			files[path.Clean(hdr.Name)] = data
+		}
+
+	case "zip":
+		r, err := zip.OpenReader(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer r.Close()
+
+		for _, f := range r.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read archive %s: %w", p, err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read archive %s: %w", p, err)
+			}
+			files[path.Clean(f.Name)] = data
+		}
+
+	default:
+		err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(p, file)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = data
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export directory %s: %w", p, err)
+		}
+	}
+
+	return files, nil
+}
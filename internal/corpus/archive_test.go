@@ -0,0 +1,134 @@
+// internal/corpus/archive_test.go
+package corpus
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+func TestExportMerge(t *testing.T) {
+	bytesTarget := &target.Target{Package: "example.com/fz", Name: "FuzzParse", Params: []string{"[]byte"}}
+	intTarget := &target.Target{Package: "example.com/fz", Name: "FuzzInt", Params: []string{"int"}}
+
+	canonical := []byte("go test fuzz v1\n[]byte(\"x\")\n")
+	inputs := map[*target.Target][][]byte{
+		bytesTarget: {
+			canonical,
+			// The same input, written by hand
+			[]byte("go test fuzz v1\r\n[]byte(\"x\")\r\n"),
+			[]byte("go test fuzz v1\n[]byte(\"\\x79\")\n"),
+			[]byte("go test fuzz v1\nstring(\"not bytes\")\n"),
+		},
+		intTarget: {
+			[]byte("go test fuzz v1\nint(5)\n"),
+			[]byte("go test fuzz v1\nint(0x5)\n"),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		archive string
+		raw     bool
+		skipped int
+		want    map[*target.Target][]string
+	}{
+		{
+			name:    "v1 keeps files as they are",
+			archive: "corpus.tar.gz",
+			want: map[*target.Target][]string{
+				bytesTarget: hashes(inputs[bytesTarget]...),
+				intTarget:   hashes(inputs[intTarget]...),
+			},
+		},
+		{
+			name:    "raw renames files written by hand",
+			archive: "corpus.zip",
+			raw:     true,
+			skipped: 1,
+			want: map[*target.Target][]string{
+				bytesTarget: hashes(canonical, []byte("go test fuzz v1\n[]byte(\"y\")\n")),
+				intTarget:   hashes(inputs[intTarget]...),
+			},
+		},
+		{
+			name:    "raw directory",
+			archive: "corpus",
+			raw:     true,
+			skipped: 1,
+			want: map[*target.Target][]string{
+				bytesTarget: hashes(canonical, []byte("go test fuzz v1\n[]byte(\"y\")\n")),
+				intTarget:   hashes(inputs[intTarget]...),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := NewCorpusManager(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			targets := []*target.Target{bytesTarget, intTarget}
+			for _, tg := range targets {
+				if _, err := src.AddAll(tg, inputs[tg], "fuzz"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			path := filepath.Join(t.TempDir(), tt.archive)
+			_, skipped, err := src.Export(path, targets, tt.raw)
+			if err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			if skipped != tt.skipped {
+				t.Errorf("skipped %d entries, want %d", skipped, tt.skipped)
+			}
+
+			a, err := OpenArchive(path)
+			if err != nil {
+				t.Fatalf("OpenArchive: %v", err)
+			}
+			dst, err := NewCorpusManager(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, at := range a.Manifest.Targets {
+				if _, err := dst.Merge(a, at); err != nil {
+					t.Fatalf("Merge %s: %v", at.Target, err)
+				}
+				// Merging again adds nothing
+				if added, err := dst.Merge(a, at); err != nil || added != 0 {
+					t.Errorf("merging %s again added %d, %v", at.Target, added, err)
+				}
+			}
+
+			for _, tg := range targets {
+				idx, err := dst.Index(tg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, e := range idx.Entries {
+					got = append(got, e.Hash)
+				}
+				slices.Sort(got)
+				if !slices.Equal(got, tt.want[tg]) {
+					t.Errorf("%s has entries %v, want %v", tg.Name, got, tt.want[tg])
+				}
+			}
+		})
+	}
+}
+
+// hashes returns the sorted hashes of inputs
+func hashes(inputs ...[]byte) []string {
+	var h []string
+	for _, data := range inputs {
+		h = append(h, hashData(data))
+	}
+	slices.Sort(h)
+	return h
+}
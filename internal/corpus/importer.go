@@ -0,0 +1,288 @@
+// internal/corpus/importer.go
+package corpus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats of raw corpora that can be imported
+const (
+	// FormatRaw is a directory tree of files holding one input each, as
+	// used by libFuzzer
+	FormatRaw = "raw"
+
+	// FormatGoFuzz is a go-fuzz working directory, whose corpus and
+	// crashers directories hold the inputs
+	FormatGoFuzz = "go-fuzz"
+
+	// FormatAFL is an AFL or AFL++ output directory, with the inputs in the
+	// queue and crashes directories of each fuzzer instance
+	FormatAFL = "afl"
+)
+
+// SourceImport marks entries imported from another corpus
+const SourceImport = "import"
+
+// RawInputs returns the files holding the inputs of a raw corpus in dir,
+// sorted by path
+func RawInputs(dir, format string) ([]string, error) {
+	var (
+		files []string
+		err   error
+	)
+	switch format {
+	case FormatRaw:
+		files, err = walkInputs(dir, func(string) bool { return true })
+
+	case FormatGoFuzz:
+		// Crashers are stored with their output and quoted form next to
+		// them, which aren't inputs
+		files, err = walkInputs(dir, func(rel string) bool {
+			parts := strings.Split(filepath.ToSlash(rel), "/")
+			switch parts[0] {
+			case "corpus":
+				return true
+			case "crashers":
+				return !strings.HasSuffix(rel, ".output") && !strings.HasSuffix(rel, ".quoted")
+			}
+			return false
+		})
+
+	case FormatAFL:
+		// The inputs are in <instance>/queue and <instance>/crashes, or in
+		// queue and crashes of a single instance. Each directory has a
+		// README.txt.
+		files, err = walkInputs(dir, func(rel string) bool {
+			parts := strings.Split(filepath.ToSlash(rel), "/")
+			if len(parts) < 2 || parts[len(parts)-1] == "README.txt" {
+				return false
+			}
+			parent := parts[len(parts)-2]
+			return parent == "queue" || parent == "crashes"
+		})
+
+	default:
+		return nil, fmt.Errorf("unknown corpus format %q (want %s, %s or %s)", format, FormatRaw, FormatGoFuzz, FormatAFL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// walkInputs returns the regular files below dir whose path relative to dir
+// is accepted. Hidden files and directories, e.g. AFL's .state, are skipped.
+func walkInputs(dir string, accept func(rel string) bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if accept(rel) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus %s: %w", dir, err)
+	}
+
+	return files, nil
+}
+
+// Split turns a raw input into the arguments of a fuzz function. Arguments
+// take the given number of bytes from the input, in order; at most one,
+// a []byte or string, takes what is left over.
+type Split struct {
+	params []string
+	widths []int
+	order  binary.ByteOrder
+}
+
+// rest is the width of the argument taking the remaining bytes
+const rest = -1
+
+// fixedWidths are the sizes of the arguments that are decoded from a fixed
+// number of bytes. int and uint are taken as 64 bits.
+var fixedWidths = map[string]int{
+	"bool": 1, "byte": 1, "uint8": 1, "int8": 1,
+	"int16": 2, "uint16": 2,
+	"rune": 4, "int32": 4, "uint32": 4, "float32": 4,
+	"int": 8, "uint": 8, "int64": 8, "uint64": 8, "float64": 8,
+}
+
+// NewSplit prepares the splitting of raw inputs for a fuzz function with
+// the given argument types. spec lists the bytes each argument takes,
+// comma-separated, with "*" for the rest, e.g. "4,*"; if it is empty, a
+// single []byte or string argument takes the whole input. Integers and
+// floats are decoded in the given byte order, "big" or "little", and take
+// their own size unless a smaller one is given.
+func NewSplit(params []string, spec, byteOrder string) (*Split, error) {
+	s := &Split{params: params}
+
+	switch byteOrder {
+	case "", "big":
+		s.order = binary.BigEndian
+	case "little":
+		s.order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid byte order %q (want big or little)", byteOrder)
+	}
+
+	signature := "func(" + strings.Join(params, ", ") + ")"
+	if spec == "" {
+		if len(params) != 1 || !isVariable(params[0]) {
+			return nil, fmt.Errorf("a target taking %s needs a split of its inputs", signature)
+		}
+		s.widths = []int{rest}
+		return s, nil
+	}
+
+	fields := strings.Split(spec, ",")
+	if len(fields) != len(params) {
+		return nil, fmt.Errorf("split %q has %d widths, want one for each argument of %s", spec, len(fields), signature)
+	}
+
+	for i, field := range fields {
+		param := params[i]
+		field = strings.TrimSpace(field)
+
+		if field == "*" {
+			if !isVariable(param) {
+				return nil, fmt.Errorf("argument %d is %s, only a []byte or string can take the rest of an input", i+1, param)
+			}
+			if slices.Contains(s.widths, rest) {
+				return nil, fmt.Errorf("split %q has more than one *", spec)
+			}
+			s.widths = append(s.widths, rest)
+			continue
+		}
+
+		width, err := strconv.Atoi(field)
+		if err != nil || width < 0 {
+			return nil, fmt.Errorf("invalid width %q in split %q", field, spec)
+		}
+		if fixed, ok := fixedWidths[param]; ok {
+			if param == "float32" || param == "float64" || param == "bool" {
+				if width != fixed {
+					return nil, fmt.Errorf("argument %d is %s, which takes %d bytes", i+1, param, fixed)
+				}
+			} else if width == 0 || width > fixed {
+				return nil, fmt.Errorf("argument %d is %s, which takes 1 to %d bytes", i+1, param, fixed)
+			}
+		} else if !isVariable(param) {
+			return nil, fmt.Errorf("argument %d has unsupported type %s", i+1, param)
+		}
+		s.widths = append(s.widths, width)
+	}
+
+	return s, nil
+}
+
+// Values splits a raw input into the arguments of the fuzz function. Inputs
+// shorter than the fixed widths are an error; without an argument taking the
+// rest, bytes beyond them are dropped and their number returned.
+func (s *Split) Values(data []byte) ([]any, int, error) {
+	need := 0
+	for _, w := range s.widths {
+		if w != rest {
+			need += w
+		}
+	}
+	if len(data) < need {
+		return nil, 0, fmt.Errorf("input has %d bytes, the split takes at least %d", len(data), need)
+	}
+
+	values := make([]any, len(s.params))
+	for i, param := range s.params {
+		width := s.widths[i]
+		if width == rest {
+			width = len(data) - need
+		} else {
+			need -= width
+		}
+
+		values[i] = s.decode(param, data[:width])
+		data = data[width:]
+	}
+
+	return values, len(data), nil
+}
+
+// decode converts the bytes taken by an argument into its value
+func (s *Split) decode(param string, b []byte) any {
+	switch param {
+	case "[]byte", "[]uint8":
+		return append([]byte{}, b...)
+	case "string":
+		return string(b)
+	case "bool":
+		return b[0] != 0
+	case "float32":
+		return math.Float32frombits(s.order.Uint32(b))
+	case "float64":
+		return math.Float64frombits(s.order.Uint64(b))
+	}
+
+	// Integers narrower than their type are padded with zeros, as if the
+	// type's full width had been given
+	full := make([]byte, 8)
+	if s.order == binary.BigEndian {
+		copy(full[8-len(b):], b)
+	} else {
+		copy(full, b)
+	}
+	n := s.order.Uint64(full)
+
+	switch param {
+	case "byte", "uint8":
+		return uint8(n)
+	case "int8":
+		return int8(n)
+	case "int16":
+		return int16(n)
+	case "uint16":
+		return uint16(n)
+	case "rune", "int32":
+		return int32(n)
+	case "uint32":
+		return uint32(n)
+	case "int":
+		return int(n)
+	case "uint":
+		return uint(n)
+	case "int64":
+		return int64(n)
+	}
+	return n
+}
+
+// isVariable reports whether arguments of a type can take any number of
+// bytes
+func isVariable(param string) bool {
+	return param == "[]byte" || param == "[]uint8" || param == "string"
+}
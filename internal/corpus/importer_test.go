@@ -0,0 +1,277 @@
+// internal/corpus/importer_test.go
+package corpus
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewSplit(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    []string
+		spec      string
+		byteOrder string
+		err       string
+	}{
+		{"whole input", []string{"[]byte"}, "", "", ""},
+		{"number and rest", []string{"uint32", "[]byte"}, "4,*", "little", ""},
+		{"narrow integer", []string{"int64", "string"}, "3, *", "big", ""},
+		{"fixed widths only", []string{"int16", "[]byte"}, "2,5", "", ""},
+		{"split needed", []string{"int", "[]byte"}, "", "", "needs a split"},
+		{"one width per argument", []string{"int", "[]byte"}, "8", "", "has 1 widths"},
+		{"two rests", []string{"[]byte", "string"}, "*,*", "", "more than one *"},
+		{"rest of a number", []string{"int", "[]byte"}, "*,4", "", "only a []byte or string"},
+		{"too wide", []string{"int16"}, "4", "", "takes 1 to 2 bytes"},
+		{"zero width number", []string{"uint8"}, "0", "", "takes 1 to 1 bytes"},
+		{"narrow float", []string{"float64"}, "4", "", "takes 8 bytes"},
+		{"wide bool", []string{"bool"}, "2", "", "takes 1 bytes"},
+		{"negative width", []string{"[]byte"}, "-1", "", "invalid width"},
+		{"unsupported type", []string{"complex64"}, "8", "", "unsupported type"},
+		{"unknown byte order", []string{"[]byte"}, "", "middle", "invalid byte order"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSplit(tt.params, tt.spec, tt.byteOrder)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("NewSplit: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("NewSplit error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSplitValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    []string
+		spec      string
+		byteOrder string
+		input     string
+		values    []any
+		dropped   int
+		err       bool
+	}{
+		{
+			name:   "whole input",
+			params: []string{"[]byte"},
+			input:  "abc",
+			values: []any{[]byte("abc")},
+		},
+		{
+			name:   "big endian",
+			params: []string{"uint32", "[]byte"},
+			spec:   "4,*",
+			input:  "\x01\x02\x03\x04rest",
+			values: []any{uint32(0x01020304), []byte("rest")},
+		},
+		{
+			name:      "little endian",
+			params:    []string{"uint32", "[]byte"},
+			spec:      "4,*",
+			byteOrder: "little",
+			input:     "\x01\x02\x03\x04rest",
+			values:    []any{uint32(0x04030201), []byte("rest")},
+		},
+		{
+			name:   "narrow width padded big endian",
+			params: []string{"int64"},
+			spec:   "2",
+			input:  "\x01\x02",
+			values: []any{int64(0x0102)},
+		},
+		{
+			name:      "narrow width padded little endian",
+			params:    []string{"int64"},
+			spec:      "2",
+			byteOrder: "little",
+			input:     "\x01\x02",
+			values:    []any{int64(0x0201)},
+		},
+		{
+			name:   "narrow width isn't sign extended",
+			params: []string{"int32"},
+			spec:   "1",
+			input:  "\xff",
+			values: []any{int32(0xff)},
+		},
+		{
+			name:   "full width wraps",
+			params: []string{"int16", "int8"},
+			spec:   "2,1",
+			input:  "\xff\xfe\x80",
+			values: []any{int16(-2), int8(-128)},
+		},
+		{
+			name:   "rest in the middle",
+			params: []string{"byte", "string", "uint16"},
+			spec:   "1,*,2",
+			input:  "\x07middle\x00\x09",
+			values: []any{byte(7), "middle", uint16(9)},
+		},
+		{
+			name:   "empty rest",
+			params: []string{"uint16", "[]byte"},
+			spec:   "2,*",
+			input:  "\x00\x01",
+			values: []any{uint16(1), []byte{}},
+		},
+		{
+			name:   "bool and floats",
+			params: []string{"bool", "float32", "float64"},
+			spec:   "1,4,8",
+			input:  "\x02\x3f\x80\x00\x00\x40\x00\x00\x00\x00\x00\x00\x00",
+			values: []any{true, float32(1), float64(2)},
+		},
+		{
+			name:   "fixed width of a slice",
+			params: []string{"[]byte", "string"},
+			spec:   "2,3",
+			input:  "abcde",
+			values: []any{[]byte("ab"), "cde"},
+		},
+		{
+			name:    "trailing bytes without a rest are dropped",
+			params:  []string{"int", "string"},
+			spec:    "8,2",
+			input:   "\x00\x00\x00\x00\x00\x00\x00\x05abXYZ",
+			values:  []any{5, "ab"},
+			dropped: 3,
+		},
+		{
+			name:   "too short",
+			params: []string{"uint32", "[]byte"},
+			spec:   "4,*",
+			input:  "\x01\x02\x03",
+			err:    true,
+		},
+		{
+			name:   "too short for a later width",
+			params: []string{"byte", "string", "uint16"},
+			spec:   "1,*,2",
+			input:  "\x07\x00",
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSplit(tt.params, tt.spec, tt.byteOrder)
+			if err != nil {
+				t.Fatalf("NewSplit: %v", err)
+			}
+
+			values, dropped, err := s.Values([]byte(tt.input))
+			if tt.err {
+				if err == nil {
+					t.Errorf("Values = %#v, want an error", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Values: %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("Values = %#v, want %#v", values, tt.values)
+			}
+			if dropped != tt.dropped {
+				t.Errorf("dropped %d bytes, want %d", dropped, tt.dropped)
+			}
+		})
+	}
+}
+
+func TestSplitNaN(t *testing.T) {
+	s, err := NewSplit([]string{"float64"}, "8", "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, _, err := s.Values([]byte("\x7f\xf8\x00\x00\x00\x00\x00\x02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bits := math.Float64bits(values[0].(float64)); bits != 0x7ff8000000000002 {
+		t.Errorf("NaN bits = %#x, want 0x7ff8000000000002", bits)
+	}
+}
+
+func TestRawInputs(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		files  []string
+		want   []string
+	}{
+		{
+			name:   "raw",
+			format: FormatRaw,
+			files:  []string{"b", "a", "sub/c", ".hidden", ".git/x"},
+			want:   []string{"a", "b", "sub/c"},
+		},
+		{
+			name:   "go-fuzz",
+			format: FormatGoFuzz,
+			files: []string{
+				"corpus/1", "crashers/2", "crashers/2.output", "crashers/2.quoted",
+				"suppressions/3", "cover.out",
+			},
+			want: []string{"corpus/1", "crashers/2"},
+		},
+		{
+			name:   "afl instances",
+			format: FormatAFL,
+			files: []string{
+				"main/queue/id:000000", "main/crashes/id:000001", "main/crashes/README.txt",
+				"main/hangs/id:000002", "main/queue/.state/x", "main/fuzzer_stats",
+				"sec/queue/id:000003",
+			},
+			want: []string{"main/crashes/id:000001", "main/queue/id:000000", "sec/queue/id:000003"},
+		},
+		{
+			name:   "afl single instance",
+			format: FormatAFL,
+			files:  []string{"queue/id:000000", "crashes/id:000001", "plot_data"},
+			want:   []string{"crashes/id:000001", "queue/id:000000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(f))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			files, err := RawInputs(dir, tt.format)
+			if err != nil {
+				t.Fatalf("RawInputs: %v", err)
+			}
+			var got []string
+			for _, f := range files {
+				rel, _ := filepath.Rel(dir, f)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("RawInputs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := RawInputs(t.TempDir(), "honggfuzz"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -3,149 +3,124 @@ package corpus
 
 import (
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/OmBiradar/go-fuzz-runner/internal/target"
 )
 
-// MinimizationStrategy defines how corpus minimization should be performed
-type MinimizationStrategy string
-
-const (
-	// NoMinimization doesn't perform any minimization
-	NoMinimization MinimizationStrategy = "none"
+// CorpusManager handles the management of fuzzing corpus. It is safe for
+// use by concurrently running targets.
+//
+// Entries are stored by the SHA-256 of their contents: every distinct input
+// is kept once in <base>/.objects and hard linked into the directories of
+// the targets that have it, which are described by an index next to them.
+type CorpusManager struct {
+	BaseDir    string
+	TargetDirs map[string]string
 
-	// CoverageMinimization minimizes based on coverage information
-	CoverageMinimization MinimizationStrategy = "coverage"
-)
+	// RunID is recorded with the entries added, to tell which run found them
+	RunID string
 
-// CorpusManager handles the management of fuzzing corpus
-type CorpusManager struct {
-	BaseDir      string
-	TargetDirs   map[string]string
-	Minimization MinimizationStrategy
+	mu      sync.Mutex
+	storeMu sync.Mutex
 }
 
 // NewCorpusManager creates a new corpus manager
-func NewCorpusManager(baseDir string, minimization MinimizationStrategy) (*CorpusManager, error) {
+func NewCorpusManager(baseDir string) (*CorpusManager, error) {
 	// Ensure base directory exists
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create corpus directory: %w", err)
 	}
 
 	return &CorpusManager{
-		BaseDir:      baseDir,
-		TargetDirs:   make(map[string]string),
-		Minimization: minimization,
+		BaseDir:    baseDir,
+		TargetDirs: make(map[string]string),
 	}, nil
 }
 
-// GetTargetDir returns the corpus directory for a specific target
+// GetTargetDir returns the corpus directory for a specific target. Targets
+// are namespaced by module, <base>/<module>/<package>/<FuzzName>, so packages
+// with the same path in different modules of a repository don't share a
+// corpus. A corpus stored by earlier versions without the module is moved
+// into place.
 func (m *CorpusManager) GetTargetDir(target *target.Target) string {
-	targetKey := fmt.Sprintf("%s.%s", target.Package, target.Name)
+	targetKey := fmt.Sprintf("%s %s.%s", target.Module, target.Package, target.Name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	if dir, ok := m.TargetDirs[targetKey]; ok {
 		return dir
 	}
 
+	legacyDir := filepath.Join(m.BaseDir, escapePath(target.Package), target.Name)
+	dir := legacyDir
+	if target.Module != "" {
+		dir = filepath.Join(m.BaseDir, escapePath(target.Module), escapePath(target.Package), target.Name)
+		migrateDir(legacyDir, dir)
+	}
+
 	// Create target-specific directory if it doesn't exist
-	dir := filepath.Join(m.BaseDir, strings.ReplaceAll(target.Package, "/", "_"), target.Name)
 	os.MkdirAll(dir, 0755)
 
 	m.TargetDirs[targetKey] = dir
 	return dir
 }
 
-// ImportNewCorpusEntries imports new corpus entries for a target
-func (m *CorpusManager) ImportNewCorpusEntries(t *target.Target, newEntriesDir string) error {
-	targetDir := m.GetTargetDir(t)
+// escapePath turns an import path into a directory name
+func escapePath(path string) string {
+	return strings.ReplaceAll(path, "/", "_")
+}
 
-	// Walk through new entries and copy them
+// migrateDir moves the corpus of a target from its legacy directory to dir,
+// unless dir already exists. The legacy package directory is removed once
+// it is empty.
+func migrateDir(legacyDir, dir string) {
+	if _, err := os.Stat(legacyDir); err != nil {
+		return
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return
+	}
+	if err := os.Rename(legacyDir, dir); err != nil {
+		return
+	}
+	os.Remove(filepath.Dir(legacyDir))
+}
+
+// ImportNewCorpusEntries imports the entries in newEntriesDir into the
+// corpus of a target and returns how many of them it didn't have yet.
+// Entries are compared by contents, whatever their file names.
+func (m *CorpusManager) ImportNewCorpusEntries(t *target.Target, newEntriesDir string) (int, error) {
 	entries, err := os.ReadDir(newEntriesDir)
 	if err != nil {
-		return fmt.Errorf("failed to read new entries directory: %w", err)
+		return 0, fmt.Errorf("failed to read new entries directory: %w", err)
 	}
 
+	var inputs [][]byte
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue // Skip directories
 		}
 
-		srcPath := filepath.Join(newEntriesDir, entry.Name())
-		dstPath := filepath.Join(targetDir, entry.Name())
-
-		// Skip if file already exists (simple deduplication)
-		if _, err := os.Stat(dstPath); err == nil {
-			continue
-		}
-
-		// Copy the file
-		if err := copyFile(srcPath, dstPath); err != nil {
-			return fmt.Errorf("failed to copy corpus entry: %w", err)
+		data, err := os.ReadFile(filepath.Join(newEntriesDir, entry.Name()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read corpus entry: %w", err)
 		}
+		inputs = append(inputs, data)
 	}
 
-	// Apply minimization if configured
-	if m.Minimization == CoverageMinimization {
-		if err := m.Minimize(t); err != nil {
-			return fmt.Errorf("corpus minimization failed: %w", err)
-		}
-	}
-
-	return nil
-}
-
-// Minimize applies corpus minimization to the target
-func (m *CorpusManager) Minimize(t *target.Target) error {
-	if m.Minimization == NoMinimization {
-		return nil
-	}
-
-	// In Go 1.18+, we can use -fuzzminimizetime to perform minimization
-	// For this example, we're just executing a simple minimization step
-
-	targetDir := m.GetTargetDir(t)
-
-	// This would run the fuzzer in minimization mode
-	// For brevity, we're not implementing the full minimization algorithm
-	cmd := exec.Command("go", "test",
-		"-run", "^$", // Don't run regular tests
-		"-fuzz", t.Name,
-		"-fuzzminimizetime", "10s",
-		"-fuzzminimize",
-		t.Package)
-
-	cmd.Env = append(os.Environ(), fmt.Sprintf("GOFUZZCACHE=%s", targetDir))
-
-	// Discard output for brevity
-	cmd.Stdout = io.Discard
-	cmd.Stderr = io.Discard
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("minimization failed: %w", err)
-	}
-
-	return nil
-}
-
-// copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-
-	out, err := os.Create(dst)
+	added, err := m.AddAll(t, inputs, SourceFuzz)
 	if err != nil {
-		return err
+		return added, fmt.Errorf("failed to store corpus entries: %w", err)
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, in)
-	return err
+	return added, nil
 }
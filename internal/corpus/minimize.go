@@ -0,0 +1,157 @@
+// internal/corpus/minimize.go
+package corpus
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+// SelectCover returns a small subset of entries that together cover
+// everything the entries cover. coverage maps the hash of each entry to the
+// coverage features it hits; entries without coverage are never selected.
+//
+// Entries are picked greedily by the number of features they add, the
+// smaller entry winning ties, and picked entries made redundant by later
+// picks are dropped again.
+func SelectCover(entries []*Entry, coverage map[string][]string) []*Entry {
+	candidates := slices.Clone(entries)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Size != candidates[j].Size {
+			return candidates[i].Size < candidates[j].Size
+		}
+		return candidates[i].Hash < candidates[j].Hash
+	})
+
+	covered := make(map[string]int)
+	var selected []*Entry
+	for {
+		var (
+			best     int
+			bestGain int
+		)
+		for i, e := range candidates {
+			gain := 0
+			for _, f := range coverage[e.Hash] {
+				if covered[f] == 0 {
+					gain++
+				}
+			}
+			// Candidates are sorted by size, so ties go to the smaller one
+			if gain > bestGain {
+				best, bestGain = i, gain
+			}
+		}
+		if bestGain == 0 {
+			break
+		}
+
+		e := candidates[best]
+		for _, f := range coverage[e.Hash] {
+			covered[f]++
+		}
+		selected = append(selected, e)
+		candidates = slices.Delete(candidates, best, best+1)
+	}
+
+	// An early pick may be covered entirely by later ones. The largest
+	// redundant entries are dropped first.
+	for i := len(selected) - 1; i >= 0; i-- {
+		e := selected[i]
+		redundant := true
+		for _, f := range coverage[e.Hash] {
+			if covered[f] < 2 {
+				redundant = false
+				break
+			}
+		}
+		if !redundant {
+			continue
+		}
+		for _, f := range coverage[e.Hash] {
+			covered[f]--
+		}
+		selected = slices.Delete(selected, i, i+1)
+	}
+
+	return selected
+}
+
+// Remove takes entries out of the corpus of a target. Stored contents that
+// no other target has are deleted.
+func (m *CorpusManager) Remove(t *target.Target, hashes []string) error {
+	dir := m.GetTargetDir(t)
+
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+
+	idx, err := m.syncIndex(t, dir)
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	kept := idx.Entries[:0]
+	for _, e := range idx.Entries {
+		if !slices.Contains(hashes, e.Hash) {
+			kept = append(kept, e)
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove corpus entry: %w", err)
+		}
+		removed = append(removed, e.Hash)
+	}
+	idx.Entries = kept
+
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := writeIndex(dir, idx); err != nil {
+		return err
+	}
+
+	return m.removeObjects(removed)
+}
+
+// referencedHashes returns the hashes of the entries of all targets, as
+// listed by their indexes
+func (m *CorpusManager) referencedHashes() (map[string]bool, error) {
+	referenced := make(map[string]bool)
+
+	err := filepath.WalkDir(m.BaseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == objectsDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, indexSuffix) {
+			return nil
+		}
+
+		idx, err := readIndex(strings.TrimSuffix(path, indexSuffix))
+		if err != nil {
+			return err
+		}
+		for _, e := range idx.Entries {
+			referenced[e.Hash] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus indexes: %w", err)
+	}
+
+	return referenced, nil
+}
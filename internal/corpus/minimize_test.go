@@ -0,0 +1,81 @@
+// internal/corpus/minimize_test.go
+package corpus
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelectCover(t *testing.T) {
+	tests := []struct {
+		name     string
+		sizes    map[string]int64
+		coverage map[string][]string
+		want     []string
+	}{
+		{
+			name:     "largest gain first",
+			sizes:    map[string]int64{"a": 1, "b": 1, "c": 1},
+			coverage: map[string][]string{"a": {"1", "2", "3"}, "b": {"1"}, "c": {"4"}},
+			want:     []string{"a", "c"},
+		},
+		{
+			name:     "smaller entry wins ties",
+			sizes:    map[string]int64{"a": 10, "b": 5},
+			coverage: map[string][]string{"a": {"1", "2"}, "b": {"1", "2"}},
+			want:     []string{"b"},
+		},
+		{
+			name:     "hash breaks ties of equal size",
+			sizes:    map[string]int64{"b": 5, "a": 5},
+			coverage: map[string][]string{"a": {"1"}, "b": {"1"}},
+			want:     []string{"a"},
+		},
+		{
+			name:  "early pick made redundant is dropped",
+			sizes: map[string]int64{"x": 1, "y": 1, "z": 1},
+			coverage: map[string][]string{
+				"x": {"1", "2", "3", "4"},
+				"y": {"1", "2", "5"},
+				"z": {"3", "4", "6"},
+			},
+			want: []string{"y", "z"},
+		},
+		{
+			name:     "entries without coverage are never selected",
+			sizes:    map[string]int64{"a": 1, "b": 1, "c": 100},
+			coverage: map[string][]string{"a": {}, "c": {"1"}},
+			want:     []string{"c"},
+		},
+		{
+			name:  "hit buckets are distinct features",
+			sizes: map[string]int64{"a": 1, "b": 2},
+			coverage: map[string][]string{
+				"a": {"f.go:1.1,2.2#1"},
+				"b": {"f.go:1.1,2.2#4"},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name:  "no entries",
+			sizes: map[string]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []*Entry
+			for hash, size := range tt.sizes {
+				entries = append(entries, &Entry{Hash: hash, Size: size})
+			}
+
+			var got []string
+			for _, e := range SelectCover(entries, tt.coverage) {
+				got = append(got, e.Hash)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
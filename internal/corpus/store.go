@@ -0,0 +1,514 @@
+// internal/corpus/store.go
+package corpus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+const (
+	// objectsDir holds the contents of all corpus entries of all targets,
+	// once per distinct input. Module paths can't start with a dot, so it
+	// never clashes with a target directory.
+	objectsDir = ".objects"
+
+	// indexSuffix names the index of a target next to its directory, which
+	// only holds entries so it can be handed to the fuzzer as it is
+	indexSuffix = ".index.json"
+
+	// indexVersion changes whenever the index format changes
+	indexVersion = 1
+)
+
+// Sources of corpus entries
+const (
+	// SourceFuzz marks entries found by the fuzzer
+	SourceFuzz = "fuzz"
+
+	// SourceExisting marks entries that were in the target directory
+	// before they were indexed, e.g. added by hand or by older versions
+	SourceExisting = "existing"
+)
+
+// Entry describes a corpus entry of a target. Entries are stored by the
+// SHA-256 of their contents.
+type Entry struct {
+	Hash    string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	AddedAt time.Time `json:"added_at"`
+	Run     string    `json:"run,omitempty"`
+	Source  string    `json:"source"`
+}
+
+// Index lists the corpus entries of a target, oldest first
+type Index struct {
+	Version int      `json:"version"`
+	Target  string   `json:"target"`
+	Module  string   `json:"module,omitempty"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Size returns the total size of the entries
+func (idx *Index) Size() int64 {
+	var size int64
+	for _, e := range idx.Entries {
+		size += e.Size
+	}
+	return size
+}
+
+// LastAdded returns when the newest entry was added, or the zero time if
+// there are no entries
+func (idx *Index) LastAdded() time.Time {
+	var last time.Time
+	for _, e := range idx.Entries {
+		if e.AddedAt.After(last) {
+			last = e.AddedAt
+		}
+	}
+	return last
+}
+
+// find returns the entry with the given hash, or nil
+func (idx *Index) find(hash string) *Entry {
+	for _, e := range idx.Entries {
+		if e.Hash == hash {
+			return e
+		}
+	}
+	return nil
+}
+
+// hashData returns the name of an entry with the given contents
+func hashData(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// isHash reports whether a file name is the name of a stored entry
+func isHash(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+// indexPath returns the index file of a target directory
+func indexPath(dir string) string {
+	return dir + indexSuffix
+}
+
+// objectPath returns where the contents of an entry are stored
+func (m *CorpusManager) objectPath(hash string) string {
+	return filepath.Join(m.BaseDir, objectsDir, hash[:2], hash)
+}
+
+// Index returns the index of a target's corpus. Files in the target
+// directory that aren't indexed yet are stored by their contents and added
+// to it, and entries whose files were removed are dropped.
+func (m *CorpusManager) Index(t *target.Target) (*Index, error) {
+	dir := m.GetTargetDir(t)
+
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+
+	idx, err := m.syncIndex(t, dir)
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Add stores an input as a corpus entry of a target. It reports whether the
+// target didn't have the input yet.
+func (m *CorpusManager) Add(t *target.Target, data []byte, source string) (bool, error) {
+	added, err := m.AddAll(t, [][]byte{data}, source)
+	return added > 0, err
+}
+
+// AddAll stores inputs as corpus entries of a target and returns how many
+// of them the target didn't have yet
+func (m *CorpusManager) AddAll(t *target.Target, inputs [][]byte, source string) (int, error) {
+	now := time.Now().UTC()
+	entries := make([]*Entry, len(inputs))
+	for i, data := range inputs {
+		entries[i] = &Entry{
+			Hash:    hashData(data),
+			Size:    int64(len(data)),
+			AddedAt: now,
+			Run:     m.RunID,
+			Source:  source,
+		}
+	}
+
+	return m.addEntries(t, entries, inputs)
+}
+
+// addEntries stores inputs described by entries, whose hashes must match
+// them, and returns how many of them the target didn't have yet
+func (m *CorpusManager) addEntries(t *target.Target, entries []*Entry, inputs [][]byte) (int, error) {
+	dir := m.GetTargetDir(t)
+
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+
+	idx, err := m.syncIndex(t, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for i, entry := range entries {
+		if idx.find(entry.Hash) != nil {
+			continue
+		}
+
+		if err := m.storeEntry(dir, entry.Hash, inputs[i]); err != nil {
+			return added, err
+		}
+		idx.Entries = append(idx.Entries, entry)
+		added++
+	}
+
+	if added == 0 {
+		return 0, nil
+	}
+
+	// Entries merged from elsewhere may be older than the ones here
+	sort.SliceStable(idx.Entries, func(i, j int) bool {
+		return idx.Entries[i].AddedAt.Before(idx.Entries[j].AddedAt)
+	})
+	return added, writeIndex(dir, idx)
+}
+
+// syncIndex loads the index of a target directory and brings it in line
+// with the files in it
+func (m *CorpusManager) syncIndex(t *target.Target, dir string) (*Index, error) {
+	idx, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	idx.Target = t.Package + "." + t.Name
+	idx.Module = t.Module
+
+	files, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read corpus directory: %w", err)
+	}
+
+	changed := false
+	present := make(map[string]bool)
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() {
+			continue
+		}
+		if isHash(name) && idx.find(name) != nil {
+			present[name] = true
+			continue
+		}
+
+		// Store the file by its contents. Its original is removed, as a
+		// duplicate or as replaced by the stored entry.
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read corpus entry: %w", err)
+		}
+		hash := hashData(data)
+
+		if name != hash {
+			if err := m.storeEntry(dir, hash, data); err != nil {
+				return nil, err
+			}
+			os.Remove(path)
+		} else if err := m.storeObject(hash, path, data); err != nil {
+			return nil, err
+		}
+
+		if !present[hash] && idx.find(hash) == nil {
+			addedAt := time.Now().UTC()
+			if info, err := f.Info(); err == nil {
+				addedAt = info.ModTime().UTC()
+			}
+			idx.Entries = append(idx.Entries, &Entry{
+				Hash:    hash,
+				Size:    int64(len(data)),
+				AddedAt: addedAt,
+				Source:  SourceExisting,
+			})
+		}
+		present[hash] = true
+		changed = true
+	}
+
+	// Entries whose files were deleted by hand are gone
+	var dropped []string
+	kept := idx.Entries[:0]
+	for _, e := range idx.Entries {
+		if present[e.Hash] {
+			kept = append(kept, e)
+		} else {
+			dropped = append(dropped, e.Hash)
+			changed = true
+		}
+	}
+	idx.Entries = kept
+
+	if !changed {
+		return idx, nil
+	}
+
+	sort.SliceStable(idx.Entries, func(i, j int) bool {
+		return idx.Entries[i].AddedAt.Before(idx.Entries[j].AddedAt)
+	})
+	if err := writeIndex(dir, idx); err != nil {
+		return nil, err
+	}
+
+	// So are their contents, unless another target has them
+	if len(dropped) > 0 {
+		if err := m.removeObjects(dropped); err != nil {
+			return nil, err
+		}
+	}
+
+	return idx, nil
+}
+
+// removeObjects deletes the stored contents of entries that no target
+// refers to any more
+func (m *CorpusManager) removeObjects(hashes []string) error {
+	referenced, err := m.referencedHashes()
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if !referenced[hash] {
+			os.Remove(m.objectPath(hash))
+		}
+	}
+
+	return nil
+}
+
+// storeEntry stores the contents of an entry and links it into a target
+// directory
+func (m *CorpusManager) storeEntry(dir, hash string, data []byte) error {
+	object := m.objectPath(hash)
+	if err := m.storeObject(hash, "", data); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+
+	// Targets share the stored contents through hard links. Where they
+	// aren't supported, e.g. across file systems, the entry is a copy.
+	path := filepath.Join(dir, hash)
+	if err := os.Link(object, path); err != nil && !errors.Is(err, os.ErrExist) {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write corpus entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// storeObject stores the contents of an entry unless they are stored
+// already. If path is set, it is a file with the contents that becomes the
+// stored object if possible.
+func (m *CorpusManager) storeObject(hash, path string, data []byte) error {
+	object := m.objectPath(hash)
+	if _, err := os.Stat(object); err == nil {
+		// Replace a separate copy of the contents by a link
+		if path != "" {
+			linkOver(object, path)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+		return fmt.Errorf("failed to create corpus object directory: %w", err)
+	}
+	if path != "" && os.Link(path, object) == nil {
+		return nil
+	}
+
+	// Write to a temporary file first so no other process sees a partial
+	// object
+	tmp, err := os.CreateTemp(filepath.Dir(object), ".object-*")
+	if err != nil {
+		return fmt.Errorf("failed to create corpus object: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write corpus object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write corpus object: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), object); err != nil {
+		return fmt.Errorf("failed to store corpus object: %w", err)
+	}
+
+	return nil
+}
+
+// linkOver replaces the file at path by a link to object, unless they are
+// the same file already. It is best effort; the copy stays if linking fails.
+func linkOver(object, path string) {
+	objectInfo, err := os.Stat(object)
+	if err != nil {
+		return
+	}
+	pathInfo, err := os.Stat(path)
+	if err != nil || os.SameFile(objectInfo, pathInfo) {
+		return
+	}
+
+	tmp := path + ".link"
+	if err := os.Link(object, tmp); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// readIndex reads the index of a target directory. A missing index is an
+// empty one.
+func readIndex(dir string) (*Index, error) {
+	idx := &Index{Version: indexVersion}
+
+	data, err := os.ReadFile(indexPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus index: %w", err)
+	}
+
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus index %s: %w", indexPath(dir), err)
+	}
+	if idx.Version != indexVersion {
+		return nil, fmt.Errorf("unsupported corpus index version %d in %s", idx.Version, indexPath(dir))
+	}
+
+	return idx, nil
+}
+
+// writeIndex replaces the index of a target directory
+func writeIndex(dir string, idx *Index) error {
+	idx.Version = indexVersion
+	if idx.Entries == nil {
+		idx.Entries = []*Entry{}
+	}
+
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode corpus index: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a torn file
+	path := indexPath(dir)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write corpus index: %w", err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// ObjectStats returns the number and total size of the distinct inputs
+// stored for all targets
+func (m *CorpusManager) ObjectStats() (int, int64, error) {
+	var (
+		count int
+		size  int64
+	)
+	err := filepath.WalkDir(filepath.Join(m.BaseDir, objectsDir), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isHash(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		count++
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read corpus objects: %w", err)
+	}
+
+	return count, size, nil
+}
+
+// Prune deletes the stored contents that no target's index refers to, e.g.
+// those left behind when writing an index failed, and returns their number
+// and total size. Entries that are still linked into a target directory are
+// stored again the next time the target is used.
+func (m *CorpusManager) Prune() (int, int64, error) {
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+
+	referenced, err := m.referencedHashes()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		count int
+		size  int64
+	)
+	err = filepath.WalkDir(filepath.Join(m.BaseDir, objectsDir), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isHash(d.Name()) || referenced[d.Name()] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		count++
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return count, size, fmt.Errorf("failed to prune corpus objects: %w", err)
+	}
+
+	return count, size, nil
+}
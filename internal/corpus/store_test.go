@@ -0,0 +1,192 @@
+// internal/corpus/store_test.go
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+var (
+	targetA = &target.Target{Package: "example.com/fz", Name: "FuzzA"}
+	targetB = &target.Target{Package: "example.com/fz", Name: "FuzzB"}
+
+	inputX = []byte("go test fuzz v1\n[]byte(\"x\")\n")
+	inputY = []byte("go test fuzz v1\n[]byte(\"y\")\n")
+)
+
+// objectCount returns the number of distinct inputs stored
+func objectCount(t *testing.T, m *CorpusManager) int {
+	t.Helper()
+	count, _, err := m.ObjectStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// entryCount returns the number of indexed entries of a target
+func entryCount(t *testing.T, m *CorpusManager, tg *target.Target) int {
+	t.Helper()
+	idx, err := m.Index(tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(idx.Entries)
+}
+
+func TestAddAll(t *testing.T) {
+	m, err := NewCorpusManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		target  *target.Target
+		inputs  [][]byte
+		added   int
+		entries int
+		objects int
+	}{
+		{"new inputs", targetA, [][]byte{inputX, inputY}, 2, 2, 2},
+		{"known input", targetA, [][]byte{inputX}, 0, 2, 2},
+		{"duplicates in one call", targetB, [][]byte{inputX, inputX}, 1, 1, 2},
+		{"shared with another target", targetB, [][]byte{inputY}, 1, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, err := m.AddAll(tt.target, tt.inputs, SourceFuzz)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if added != tt.added {
+				t.Errorf("added %d, want %d", added, tt.added)
+			}
+			if n := entryCount(t, m, tt.target); n != tt.entries {
+				t.Errorf("%d entries, want %d", n, tt.entries)
+			}
+			if n := objectCount(t, m); n != tt.objects {
+				t.Errorf("%d stored inputs, want %d", n, tt.objects)
+			}
+		})
+	}
+}
+
+func TestIndexAdoptsFiles(t *testing.T) {
+	m, err := NewCorpusManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add(targetA, inputX, SourceFuzz); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files named by the fuzzer are renamed after their contents, and
+	// copies of known entries are dropped
+	dir := m.GetTargetDir(targetA)
+	for name, data := range map[string][]byte{"seed-y": inputY, "copy-of-x": inputX} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := m.Index(targetA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Entries) != 2 {
+		t.Fatalf("%d entries, want 2", len(idx.Entries))
+	}
+	adopted := idx.find(hashData(inputY))
+	if adopted == nil || adopted.Source != SourceExisting {
+		t.Errorf("file added by hand wasn't indexed: %+v", adopted)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if !isHash(f.Name()) {
+			t.Errorf("file %s wasn't renamed", f.Name())
+		}
+	}
+	if n := objectCount(t, m); n != 2 {
+		t.Errorf("%d stored inputs, want 2", n)
+	}
+}
+
+func TestDeletedEntries(t *testing.T) {
+	m, err := NewCorpusManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddAll(targetA, [][]byte{inputX, inputY}, SourceFuzz); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add(targetB, inputX, SourceFuzz); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting files by hand drops the entries, and the contents no other
+	// target has
+	for _, data := range [][]byte{inputX, inputY} {
+		if err := os.Remove(filepath.Join(m.GetTargetDir(targetA), hashData(data))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := entryCount(t, m, targetA); n != 0 {
+		t.Errorf("%d entries left, want 0", n)
+	}
+	if _, err := os.Stat(m.objectPath(hashData(inputY))); !os.IsNotExist(err) {
+		t.Errorf("contents of the deleted entry are still stored: %v", err)
+	}
+	if _, err := os.Stat(m.objectPath(hashData(inputX))); err != nil {
+		t.Errorf("contents shared with another target were deleted: %v", err)
+	}
+
+	if err := m.Remove(targetB, []string{hashData(inputX)}); err != nil {
+		t.Fatal(err)
+	}
+	if n := objectCount(t, m); n != 0 {
+		t.Errorf("%d stored inputs left, want 0", n)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	m, err := NewCorpusManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add(targetA, inputX, SourceFuzz); err != nil {
+		t.Fatal(err)
+	}
+
+	// An input stored without its index being written, as after a failed
+	// write
+	if err := m.storeObject(hashData(inputY), "", inputY); err != nil {
+		t.Fatal(err)
+	}
+
+	count, size, err := m.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || size != int64(len(inputY)) {
+		t.Errorf("pruned %d inputs, %d bytes, want 1, %d", count, size, len(inputY))
+	}
+	if n := objectCount(t, m); n != 1 {
+		t.Errorf("%d stored inputs left, want 1", n)
+	}
+	if n := entryCount(t, m, targetA); n != 1 {
+		t.Errorf("%d entries left, want 1", n)
+	}
+
+	if count, _, err := m.Prune(); err != nil || count != 0 {
+		t.Errorf("pruning again removed %d, %v", count, err)
+	}
+}
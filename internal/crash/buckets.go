@@ -0,0 +1,257 @@
+// internal/crash/buckets.go
+package crash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bucketsFile holds the buckets of a crash store
+const bucketsFile = "buckets.json"
+
+// Bucket statuses
+const (
+	// StatusOpen marks a bug that has not been fixed yet
+	StatusOpen = "open"
+
+	// StatusFixed marks a bug that was fixed and hasn't been seen since
+	StatusFixed = "fixed"
+
+	// StatusRegressed marks a fixed bug that came back
+	StatusRegressed = "regressed"
+)
+
+// Bucket groups crashes that share a signature, i.e. most likely the same bug
+type Bucket struct {
+	ID        string    `json:"id"`
+	Package   string    `json:"package"`
+	Name      string    `json:"name"`
+	Message   string    `json:"message"`
+	Frames    []string  `json:"frames"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     int       `json:"count"`
+	Crashes   []string  `json:"crashes"`
+	Status    string    `json:"status"`
+	FixedAt   time.Time `json:"fixed_at,omitzero"`
+}
+
+// Buckets returns all known buckets, most recently seen first
+func (s *Store) Buckets() ([]*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.loadBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Bucket, 0, len(buckets))
+	for _, b := range buckets {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].LastSeen.Equal(list[j].LastSeen) {
+			return list[i].LastSeen.After(list[j].LastSeen)
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	return list, nil
+}
+
+// Bucket returns the bucket with the given ID or unique ID prefix
+func (s *Store) Bucket(id string) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.loadBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	return findBucket(buckets, id)
+}
+
+// MarkFixed marks a bucket as fixed. If the crash shows up again, the
+// bucket is reopened as regressed.
+func (s *Store) MarkFixed(id string) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.loadBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := findBucket(buckets, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Status = StatusFixed
+	b.FixedAt = time.Now()
+
+	return b, s.saveBuckets(buckets)
+}
+
+// Delete removes a bucket together with the stored inputs no other bucket
+// refers to. The same input lands in several buckets of a target when its
+// failure changed between runs.
+func (s *Store) Delete(id string) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.loadBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := findBucket(buckets, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(buckets, b.ID)
+
+	dir := s.targetDir(b.Package, b.Name)
+	kept := make(map[string]bool)
+	for _, other := range buckets {
+		if s.targetDir(other.Package, other.Name) == dir {
+			for _, hash := range other.Crashes {
+				kept[hash] = true
+			}
+		}
+	}
+
+	for _, hash := range b.Crashes {
+		if kept[hash] {
+			continue
+		}
+		path := filepath.Join(dir, hash)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove crash input: %w", err)
+		}
+		os.Remove(path + ".json")
+	}
+
+	return b, s.saveBuckets(buckets)
+}
+
+// record files a crash into its bucket, creating the bucket the first time
+// the signature is seen
+func (s *Store) record(c *Crash) (*Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.loadBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	failure := ParseFailure(c.Output)
+	id := failure.Signature(c.Package, c.Name, s.MatchLines)
+
+	b, ok := buckets[id]
+	if !ok {
+		b = &Bucket{
+			ID:        id,
+			Package:   c.Package,
+			Name:      c.Name,
+			Message:   failure.Message,
+			Frames:    failure.NormalizedFrames(s.MatchLines),
+			FirstSeen: c.FoundAt,
+			Status:    StatusOpen,
+		}
+		buckets[id] = b
+	}
+
+	if b.Status == StatusFixed {
+		b.Status = StatusRegressed
+	}
+
+	b.LastSeen = c.FoundAt
+	b.Count++
+	if !slices.Contains(b.Crashes, c.Hash) {
+		b.Crashes = append(b.Crashes, c.Hash)
+	}
+
+	if err := s.saveBuckets(buckets); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// loadBuckets reads the buckets file, which may not exist yet
+func (s *Store) loadBuckets() (map[string]*Bucket, error) {
+	buckets := make(map[string]*Bucket)
+
+	data, err := os.ReadFile(filepath.Join(s.BaseDir, bucketsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return buckets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crash buckets: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to parse crash buckets: %w", err)
+	}
+
+	// Buckets written before statuses existed are open
+	for _, b := range buckets {
+		if b.Status == "" {
+			b.Status = StatusOpen
+		}
+	}
+
+	return buckets, nil
+}
+
+// saveBuckets replaces the buckets file
+func (s *Store) saveBuckets(buckets map[string]*Bucket) error {
+	data, err := json.MarshalIndent(buckets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode crash buckets: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a torn file
+	path := filepath.Join(s.BaseDir, bucketsFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write crash buckets: %w", err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// findBucket looks up a bucket by ID or unique ID prefix
+func findBucket(buckets map[string]*Bucket, id string) (*Bucket, error) {
+	if b, ok := buckets[id]; ok {
+		return b, nil
+	}
+
+	var match *Bucket
+	for bucketID, b := range buckets {
+		if !strings.HasPrefix(bucketID, id) {
+			continue
+		}
+		if match != nil {
+			return nil, fmt.Errorf("crash ID %q is ambiguous", id)
+		}
+		match = b
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("no crash with ID %q", id)
+	}
+
+	return match, nil
+}
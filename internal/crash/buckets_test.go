@@ -0,0 +1,143 @@
+// internal/crash/buckets_test.go
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+func TestSameFailureOfTwoTargets(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(input, []byte("go test fuzz v1\nstring(\"x\")\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	targets := []*target.Target{
+		{Package: "example.com/fz", Name: "FuzzY"},
+		{Package: "example.com/fz", Name: "FuzzZ"},
+	}
+	var buckets []*Bucket
+	for _, tg := range targets {
+		_, b, err := s.Save(tg, input, fatalOutput)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buckets = append(buckets, b)
+	}
+	if buckets[0].ID == buckets[1].ID {
+		t.Fatalf("both targets were filed into bucket %s", buckets[0].ID)
+	}
+
+	for i, b := range buckets {
+		crashes, err := s.Crashes(b)
+		if err != nil {
+			t.Fatalf("crashes of %s: %v", b.Name, err)
+		}
+		if len(crashes) != 1 || crashes[0].Name != targets[i].Name {
+			t.Errorf("bucket of %s has crashes %v", targets[i].Name, crashes)
+		}
+	}
+
+	// Deleting a bucket removes the inputs of its own target only
+	if _, err := s.Delete(buckets[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Crashes(buckets[1]); err != nil {
+		t.Errorf("crashes of the other target after delete: %v", err)
+	}
+	entries, err := os.ReadDir(s.TargetDir(targets[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d files left in the crash directory of the deleted bucket", len(entries))
+	}
+}
+
+func TestDeleteKeepsSharedInputs(t *testing.T) {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tg := &target.Target{Package: "example.com/fz", Name: "FuzzX"}
+
+	inputs := make(map[string]string)
+	for _, name := range []string{"shared", "first", "second"} {
+		inputs[name] = filepath.Join(t.TempDir(), name)
+		data := "go test fuzz v1\nstring(\"" + name + "\")\n"
+		if err := os.WriteFile(inputs[name], []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The shared input failed differently in two runs, so it is in both
+	// buckets
+	otherOutput := strings.Replace(panicOutput, "panic: boom", "panic: bust", 1)
+	saves := []struct {
+		input, output string
+	}{
+		{"shared", panicOutput},
+		{"first", panicOutput},
+		{"shared", otherOutput},
+		{"second", otherOutput},
+	}
+	hashes := make(map[string]string)
+	var buckets []*Bucket
+	for _, save := range saves {
+		c, b, err := s.Save(tg, inputs[save.input], save.output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[save.input] = c.Hash
+		if !slices.ContainsFunc(buckets, func(other *Bucket) bool { return other.ID == b.ID }) {
+			buckets = append(buckets, b)
+		}
+	}
+	if len(buckets) != 2 {
+		t.Fatalf("inputs were filed into %d buckets, want 2", len(buckets))
+	}
+
+	stored := func() []string {
+		var names []string
+		entries, _ := os.ReadDir(s.TargetDir(tg))
+		for _, entry := range entries {
+			for name, hash := range hashes {
+				if entry.Name() == hash {
+					names = append(names, name)
+				}
+			}
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	tests := []struct {
+		bucket *Bucket
+		want   []string
+	}{
+		{buckets[0], []string{"second", "shared"}},
+		{buckets[1], nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := s.Delete(tt.bucket.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got := stored(); !slices.Equal(got, tt.want) {
+			t.Errorf("after deleting %s: stored %v, want %v", tt.bucket.Message, got, tt.want)
+		}
+	}
+	if entries, _ := os.ReadDir(s.TargetDir(tg)); len(entries) != 0 {
+		t.Errorf("%d files left after deleting every bucket", len(entries))
+	}
+}
@@ -0,0 +1,44 @@
+// internal/crash/output.go
+package crash
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// failingInputLine matches the line go test prints after writing a crasher,
+// e.g. "Failing input written to testdata/fuzz/FuzzX/5d383a64e88cd990"
+var failingInputLine = regexp.MustCompile(`Failing input written to (\S+)`)
+
+// FailingInput returns the path of the failing input reported in go test
+// output, resolved against the package directory
+func FailingInput(output, pkgDir string) (string, bool) {
+	m := failingInputLine.FindStringSubmatch(output)
+	if m == nil {
+		return "", false
+	}
+
+	path := m[1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(pkgDir, path)
+	}
+
+	return path, true
+}
+
+// FailureText returns the failure report from go test output, from the
+// first "--- FAIL" line up to where the failing input was written
+func FailureText(output string) string {
+	start := strings.Index(output, "--- FAIL")
+	if start < 0 {
+		return strings.TrimSpace(output)
+	}
+
+	text := output[start:]
+	if loc := failingInputLine.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,129 @@
+// internal/crash/store.go
+package crash
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+// Crash describes a failing input found while fuzzing a target
+type Crash struct {
+	Package   string    `json:"package"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	InputPath string    `json:"input_path"`
+	Output    string    `json:"output"`
+	FoundAt   time.Time `json:"found_at"`
+	BucketID  string    `json:"bucket_id"`
+}
+
+// Store keeps failing inputs and their details outside of the fuzzed
+// source tree, one directory per target, and groups them into buckets
+// that persist across runs. It is safe for concurrent use.
+type Store struct {
+	BaseDir string
+
+	// MatchLines makes line numbers part of crash signatures, so the same
+	// function failing at different lines ends up in different buckets
+	MatchLines bool
+
+	mu sync.Mutex
+}
+
+// NewStore creates a new crash store
+func NewStore(baseDir string) (*Store, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	return &Store{BaseDir: baseDir}, nil
+}
+
+// TargetDir returns the directory holding the crashers of a target
+func (s *Store) TargetDir(t *target.Target) string {
+	return s.targetDir(t.Package, t.Name)
+}
+
+// targetDir returns the directory holding the crashers of a package's target
+func (s *Store) targetDir(pkg, name string) string {
+	return filepath.Join(s.BaseDir, strings.ReplaceAll(pkg, "/", "_"), name)
+}
+
+// Crashes returns the stored crashes of a bucket, oldest first
+func (s *Store) Crashes(b *Bucket) ([]*Crash, error) {
+	var crashes []*Crash
+	for _, hash := range b.Crashes {
+		path := filepath.Join(s.targetDir(b.Package, b.Name), hash)
+
+		data, err := os.ReadFile(path + ".json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read crash %s: %w", hash, err)
+		}
+
+		c := &Crash{}
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("failed to parse crash %s: %w", hash, err)
+		}
+
+		// The store may have moved since the crash was saved
+		c.InputPath = path
+		crashes = append(crashes, c)
+	}
+
+	return crashes, nil
+}
+
+// Save copies the failing input at inputPath into the store together with
+// the failure output reported for it, and files it into its bucket
+func (s *Store) Save(t *target.Target, inputPath, output string) (*Crash, *Bucket, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read failing input: %w", err)
+	}
+
+	dir := s.TargetDir(t)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	// Name inputs the same way the Go fuzzer does, so they can be dropped
+	// into testdata/fuzz/<FuzzName> unchanged
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))[:16]
+
+	c := &Crash{
+		Package:   t.Package,
+		Name:      t.Name,
+		Hash:      hash,
+		InputPath: filepath.Join(dir, hash),
+		Output:    output,
+		FoundAt:   time.Now(),
+	}
+
+	b, err := s.record(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.BucketID = b.ID
+
+	if err := os.WriteFile(c.InputPath, data, 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to store failing input: %w", err)
+	}
+
+	meta, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode crash details: %w", err)
+	}
+	if err := os.WriteFile(c.InputPath+".json", meta, 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to store crash details: %w", err)
+	}
+
+	return c, b, nil
+}
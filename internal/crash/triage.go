@@ -0,0 +1,155 @@
+// internal/crash/triage.go
+package crash
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxSignatureFrames is the number of top frames that make up a signature
+const maxSignatureFrames = 5
+
+// Frame is a single stack frame of a failure
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     string `json:"line"`
+}
+
+// Failure is a parsed failure report of a fuzz target
+type Failure struct {
+	Message string  `json:"message"`
+	Frames  []Frame `json:"frames"`
+}
+
+var (
+	// testing.go:2076: panic: boom
+	panicLine = regexp.MustCompile(`^(?:\S+\.go:\d+: )?panic: (.*)$`)
+
+	// fz_test.go:12: unexpected value
+	errorLine = regexp.MustCompile(`^(\S+\.go):(\d+): (.*)$`)
+
+	// /tmp/fz/fz_test.go:9 +0x136
+	fileLine = regexp.MustCompile(`^(\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+	// Call arguments, e.g. "(0x0?, {0x8dd837e8478, 0x4, 0x0?})"
+	callArgs = regexp.MustCompile(`\([^()]*\)$`)
+
+	// Values that differ between otherwise identical failures
+	hexNumber = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	decNumber = regexp.MustCompile(`\d+`)
+)
+
+// ignoredFramePrefixes lists functions that appear in every failure and
+// say nothing about the bug itself
+var ignoredFramePrefixes = []string{
+	"runtime.",
+	"runtime/debug.",
+	"testing.",
+	"reflect.",
+	"internal/fuzz.",
+}
+
+// ParseFailure extracts the panic or error message and the stack of the
+// failing goroutine from a go test failure report
+func ParseFailure(output string) *Failure {
+	f := &Failure{}
+	inStack := false
+
+	lines := strings.Split(output, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case f.Message == "" && panicLine.MatchString(line):
+			f.Message = "panic: " + panicLine.FindStringSubmatch(line)[1]
+
+		case strings.HasPrefix(line, "goroutine ") && strings.HasSuffix(line, ":"):
+			// Only the first goroutine is the one that failed
+			inStack = len(f.Frames) == 0
+
+		case inStack && line == "":
+			inStack = false
+
+		case inStack && !strings.HasPrefix(line, "created by ") && i+1 < len(lines):
+			m := fileLine.FindStringSubmatch(strings.TrimSpace(lines[i+1]))
+			if m == nil {
+				continue
+			}
+			f.Frames = append(f.Frames, Frame{
+				Function: callArgs.ReplaceAllString(line, ""),
+				File:     m[1],
+				Line:     m[2],
+			})
+			i++
+
+		case f.Message == "" && errorLine.MatchString(line):
+			// t.Error/t.Fatal failures have no stack, only a location
+			m := errorLine.FindStringSubmatch(line)
+			f.Message = m[3]
+			f.Frames = append(f.Frames, Frame{File: m[1], Line: m[2]})
+		}
+	}
+
+	return f
+}
+
+// NormalizedFrames returns the frames that identify the failure, without
+// runtime and testing frames, optionally with line numbers
+func (f *Failure) NormalizedFrames(withLines bool) []string {
+	var frames []string
+	for _, fr := range f.Frames {
+		if ignoredFrame(fr.Function) {
+			continue
+		}
+
+		name := fr.Function
+		if name == "" {
+			name = fr.File
+		}
+		if withLines {
+			name = fmt.Sprintf("%s:%s", name, fr.Line)
+		}
+
+		frames = append(frames, name)
+		if len(frames) == maxSignatureFrames {
+			break
+		}
+	}
+
+	return frames
+}
+
+// Signature returns a short identifier shared by failures of a package's
+// target with the same message shape and top frames. Failures of different
+// targets never share one, even if their messages and frames match, e.g.
+// t.Fatal failures whose only frame is the test file.
+func (f *Failure) Signature(pkg, name string, withLines bool) string {
+	message := hexNumber.ReplaceAllString(f.Message, "N")
+	message = decNumber.ReplaceAllString(message, "N")
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s.%s\n", pkg, name)
+	fmt.Fprintln(h, message)
+	for _, frame := range f.NormalizedFrames(withLines) {
+		fmt.Fprintln(h, frame)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))[:12]
+}
+
+// ignoredFrame reports whether a frame belongs to the runtime or the
+// testing machinery
+func ignoredFrame(function string) bool {
+	if function == "panic" {
+		return true
+	}
+	for _, prefix := range ignoredFramePrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+// internal/crash/triage_test.go
+package crash
+
+import (
+	"slices"
+	"testing"
+)
+
+const panicOutput = `--- FAIL: FuzzX (0.05s)
+    --- FAIL: FuzzX (0.00s)
+        testing.go:1591: panic: boom at 0x1234
+            goroutine 21 [running]:
+            runtime/debug.Stack()
+            	/usr/lib/go/src/runtime/debug/stack.go:26 +0x5e
+            testing.tRunner.func1()
+            	/usr/lib/go/src/testing/testing.go:1591 +0x1c8
+            panic({0x5b2d40?, 0x6a3c10?})
+            	/usr/lib/go/src/runtime/panic.go:770 +0x132
+            example.com/fz.parse({0xc000012345, 0x2, 0x8})
+            	/tmp/fz/fz.go:12 +0x85
+            example.com/fz.FuzzX.func1(0x0?, {0xc000012345, 0x2, 0x8}, 0x3)
+            	/tmp/fz/fz_test.go:9 +0x136
+            reflect.Value.call({0x5a8a40?, 0x5f1b58?, 0x13?}, {0x5e1f8f, 0x4}, {0xc00007e660, 0x3, 0x4?})
+            	/usr/lib/go/src/reflect/value.go:596 +0xca6
+`
+
+const fatalOutput = `--- FAIL: FuzzY (0.01s)
+    --- FAIL: FuzzY (0.00s)
+        fz_test.go:21: unexpected length 12
+`
+
+func TestParseFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		message string
+		frames  []string
+	}{
+		{
+			name:    "panic",
+			output:  panicOutput,
+			message: "panic: boom at 0x1234",
+			frames:  []string{"example.com/fz.parse:12", "example.com/fz.FuzzX.func1:9"},
+		},
+		{
+			name:    "fatal",
+			output:  fatalOutput,
+			message: "unexpected length 12",
+			frames:  []string{"fz_test.go:21"},
+		},
+		{
+			name:   "no failure",
+			output: "ok  \texample.com/fz\t0.01s\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := ParseFailure(tt.output)
+			if f.Message != tt.message {
+				t.Errorf("message = %q, want %q", f.Message, tt.message)
+			}
+			if frames := f.NormalizedFrames(true); !slices.Equal(frames, tt.frames) {
+				t.Errorf("frames = %q, want %q", frames, tt.frames)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	parse := func(output string) *Failure { return ParseFailure(output) }
+	panicAt := func(addr, line string) string {
+		return "panic: boom at " + addr + "\n\ngoroutine 7 [running]:\nexample.com/fz.parse(0x1)\n\t/tmp/fz/fz.go:" + line + " +0x85\n"
+	}
+
+	tests := []struct {
+		name      string
+		a, b      *Failure
+		pkgB      string
+		nameB     string
+		withLines bool
+		same      bool
+	}{
+		{
+			name: "numbers in messages are ignored",
+			a:    parse(panicAt("0x1234", "12")),
+			b:    parse(panicAt("0xbeef", "12")),
+			same: true,
+		},
+		{
+			name: "lines are ignored by default",
+			a:    parse(panicAt("0x1", "12")),
+			b:    parse(panicAt("0x1", "40")),
+			same: true,
+		},
+		{
+			name:      "lines tell failures apart if asked to",
+			a:         parse(panicAt("0x1", "12")),
+			b:         parse(panicAt("0x1", "40")),
+			withLines: true,
+		},
+		{
+			name: "different frames",
+			a:    parse(panicAt("0x1", "12")),
+			b:    parse("panic: boom at 0x1\n\ngoroutine 7 [running]:\nexample.com/fz.decode(0x1)\n\t/tmp/fz/fz.go:12 +0x85\n"),
+		},
+		{
+			name: "different messages",
+			a:    parse(fatalOutput),
+			b:    parse("        fz_test.go:21: missing header\n"),
+		},
+		{
+			name:  "different targets",
+			a:     parse(fatalOutput),
+			b:     parse(fatalOutput),
+			nameB: "FuzzZ",
+		},
+		{
+			name: "different packages",
+			a:    parse(fatalOutput),
+			b:    parse(fatalOutput),
+			pkgB: "example.com/other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgB, nameB := "example.com/fz", "FuzzY"
+			if tt.pkgB != "" {
+				pkgB = tt.pkgB
+			}
+			if tt.nameB != "" {
+				nameB = tt.nameB
+			}
+
+			a := tt.a.Signature("example.com/fz", "FuzzY", tt.withLines)
+			b := tt.b.Signature(pkgB, nameB, tt.withLines)
+			if (a == b) != tt.same {
+				t.Errorf("signatures %s and %s, want same = %v", a, b, tt.same)
+			}
+			if len(a) != 12 {
+				t.Errorf("signature %q has length %d, want 12", a, len(a))
+			}
+		})
+	}
+}
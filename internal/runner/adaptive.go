@@ -0,0 +1,319 @@
+// internal/runner/adaptive.go
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+// discount is the weight the statistics of earlier rounds keep in every new
+// round, so a target that stops making progress loses its lead
+const discount = 0.8
+
+// exploration balances trying targets with few recent rounds against
+// replaying the ones with the best rewards
+const exploration = 0.5
+
+// Reasons recorded for round decisions
+const (
+	ReasonUnexplored   = "unexplored"
+	ReasonHighestScore = "highest score"
+	ReasonNotChosen    = "not chosen"
+)
+
+// Round records the decisions taken for one round of the adaptive strategy
+type Round struct {
+	Index     int              `json:"index"`
+	StartedAt time.Time        `json:"started_at"`
+	Duration  time.Duration    `json:"duration"`
+	Decisions []*RoundDecision `json:"decisions"`
+}
+
+// RoundDecision is the state of one target when a round was planned and,
+// if it was chosen, what the round brought
+type RoundDecision struct {
+	Target string  `json:"target"`
+	Plays  float64 `json:"plays"`
+	Mean   float64 `json:"mean_reward"`
+	Score  float64 `json:"score"`
+	Chosen bool    `json:"chosen"`
+	Reason string  `json:"reason"`
+
+	NewInteresting int     `json:"new_interesting,omitempty"`
+	CoverageGain   int     `json:"coverage_gain,omitempty"`
+	Reward         float64 `json:"reward,omitempty"`
+	Crashed        bool    `json:"crashed,omitempty"`
+}
+
+// arm is a target as seen by the bandit choosing which targets to run
+type arm struct {
+	target *target.Target
+	weight float64
+
+	// Discounted number of rounds and sum of their rewards
+	plays   float64
+	rewards float64
+
+	// result accumulates the results of all rounds, retired is set once the
+	// target crashed
+	result  *Result
+	retired bool
+}
+
+// score is the upper confidence bound of the arm's reward. Arms that never
+// ran score infinitely high so every target runs at least once.
+func (a *arm) score(totalPlays float64) float64 {
+	if a.plays == 0 {
+		return math.Inf(1)
+	}
+	bonus := math.Sqrt(2 * math.Log(max(totalPlays, 1)) / a.plays)
+	return a.rewards/a.plays + exploration*bonus
+}
+
+// decay discounts the statistics of the rounds so far
+func (a *arm) decay() {
+	a.plays *= discount
+	a.rewards *= discount
+}
+
+// play records the reward of a round the arm ran in
+func (a *arm) play(r float64) {
+	a.plays++
+	a.rewards += r
+}
+
+// merge adds the result of a round to the accumulated result
+func (a *arm) merge(res *Result) {
+	if a.result == nil {
+		res.Rounds = 1
+		a.result = res
+		return
+	}
+
+	r := a.result
+	for _, s := range res.Samples {
+		s.Elapsed += r.Duration
+		r.Samples = append(r.Samples, s)
+	}
+
+	r.Rounds++
+	r.Duration += res.Duration
+	r.Interrupted = res.Interrupted
+	r.NewCorpusItems += res.NewCorpusItems
+	r.Coverage = res.Coverage
+	r.Execs += res.Execs
+	r.NewInteresting += res.NewInteresting
+	if r.Duration > 0 {
+		r.ExecsPerSec = float64(r.Execs) / r.Duration.Seconds()
+	}
+
+	if !res.Success {
+		r.Success = false
+		r.ErrorMessage = res.ErrorMessage
+		r.Crash = res.Crash
+		r.CrashBucket = res.CrashBucket
+		r.CrashInputs = append(r.CrashInputs, res.CrashInputs...)
+	}
+}
+
+// reward turns the progress of a round into a reward between 0 and 1 that
+// grows with the number of new interesting inputs and coverage bits found
+func reward(res *Result) (float64, int) {
+	gain := max(int(res.Coverage-res.BaselineCoverage), 0)
+	return 1 - 1/(1+float64(res.NewInteresting+gain)), gain
+}
+
+// runAdaptive runs targets in rounds of RoundTime until the run's time is
+// used up. Every round, the targets with the highest upper confidence bound
+// of their reward run side by side; targets that crashed are retired.
+func (e *FuzzEngine) runAdaptive(ctx context.Context) error {
+	plans := Plan(e.Config, e.Targets)
+
+	var (
+		arms  []*arm
+		total time.Duration
+	)
+	for i, t := range e.Targets {
+		total += plans[i].Duration
+
+		// A zero weight keeps a target out of the run altogether
+		if !plans[i].Override && plans[i].weight() <= 0 {
+			continue
+		}
+		weight := plans[i].weight()
+		if plans[i].Override {
+			weight = 1
+		}
+		arms = append(arms, &arm{target: t, weight: weight})
+	}
+
+	// Without a budget, the run fuzzes as long as the fixed strategy would
+	if e.Config.Budget == 0 {
+		lanes := planLanes(e.Config.Parallelism, e.Config.ConcurrentTargets, len(arms))
+		total /= time.Duration(len(lanes))
+		total += e.BuildDuration
+	} else {
+		total = e.Config.Budget
+	}
+	deadline := e.startedAt.Add(total - min(total/20, maxStopMargin))
+
+	// Stop rounds that would run past the end, e.g. because of build time
+	runCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	var err error
+	for index := 1; err == nil && ctx.Err() == nil; index++ {
+		remaining := time.Until(deadline)
+		if remaining < minAllotment {
+			break
+		}
+
+		var active []*arm
+		for _, a := range arms {
+			if !a.retired {
+				active = append(active, a)
+			}
+		}
+		if len(active) == 0 {
+			break
+		}
+
+		round := &Round{
+			Index:     index,
+			StartedAt: time.Now(),
+			Duration:  min(e.Config.RoundTime, remaining),
+		}
+		e.Rounds = append(e.Rounds, round)
+
+		err = e.runRound(ctx, runCtx, round, active)
+	}
+
+	for _, a := range arms {
+		if a.result != nil {
+			e.Results = append(e.Results, a.result)
+		}
+	}
+	for _, t := range e.Targets {
+		if !e.ran(t) && err == nil {
+			e.Skipped = append(e.Skipped, t)
+		}
+	}
+
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	return err
+}
+
+// runRound chooses the targets of a round, runs them and updates their
+// statistics
+func (e *FuzzEngine) runRound(ctx, runCtx context.Context, round *Round, active []*arm) error {
+	var totalPlays float64
+	for _, a := range active {
+		totalPlays += a.plays
+	}
+
+	// Order by score, falling back to the configured weights and the
+	// discovery order
+	scores := make(map[*arm]float64, len(active))
+	for _, a := range active {
+		scores[a] = a.score(totalPlays)
+	}
+	sorted := append([]*arm(nil), active...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if scores[sorted[i]] != scores[sorted[j]] {
+			return scores[sorted[i]] > scores[sorted[j]]
+		}
+		return sorted[i].weight > sorted[j].weight
+	})
+
+	lanes := planLanes(e.Config.Parallelism, e.Config.ConcurrentTargets, len(active))
+	decisions := make(map[*arm]*RoundDecision, len(active))
+	for i, a := range sorted {
+		d := &RoundDecision{
+			Target: a.target.Package + "." + a.target.Name,
+			Plays:  a.plays,
+			Chosen: i < len(lanes),
+			Reason: ReasonNotChosen,
+		}
+		if a.plays > 0 {
+			d.Mean = a.rewards / a.plays
+			d.Score = scores[a]
+		}
+		if d.Chosen {
+			d.Reason = ReasonHighestScore
+			if a.plays == 0 {
+				d.Reason = ReasonUnexplored
+			}
+		}
+
+		decisions[a] = d
+		round.Decisions = append(round.Decisions, d)
+	}
+
+	chosen := sorted[:min(len(lanes), len(sorted))]
+	results := make([]*Result, len(chosen))
+	errs := make([]error, len(chosen))
+
+	var wg sync.WaitGroup
+	for i, a := range chosen {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			results[i] = &Result{Target: a.target}
+			errs[i] = e.fuzz(ctx, runCtx, a.target, lanes[i].workers, round.Duration, results[i])
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t := chosen[i].target
+			return fmt.Errorf("failed to run target %s.%s: %w", t.Package, t.Name, err)
+		}
+	}
+
+	// Older rounds count less, so plateaued targets fall behind
+	for _, a := range active {
+		a.decay()
+	}
+
+	for i, a := range chosen {
+		res := results[i]
+		r, gain := reward(res)
+
+		a.play(r)
+		a.merge(res)
+
+		d := decisions[a]
+		d.NewInteresting = res.NewInteresting
+		d.CoverageGain = gain
+		d.Reward = r
+
+		// A crashing target would most likely hit the same bug again
+		if !res.Success {
+			a.retired = true
+			d.Crashed = true
+		}
+	}
+
+	return nil
+}
+
+// ran reports whether a target has a result
+func (e *FuzzEngine) ran(t *target.Target) bool {
+	for _, r := range e.Results {
+		if r.Target == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// internal/runner/adaptive_test.go
+package runner
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReward(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *Result
+		reward float64
+		gain   int
+	}{
+		{"no progress", &Result{Coverage: 10, BaselineCoverage: 10}, 0, 0},
+		{"one interesting input", &Result{NewInteresting: 1}, 0.5, 0},
+		{"coverage gain", &Result{Coverage: 13, BaselineCoverage: 10}, 0.75, 3},
+		{"both", &Result{NewInteresting: 2, Coverage: 12, BaselineCoverage: 10}, 0.8, 2},
+		{"coverage loss counts as none", &Result{Coverage: 5, BaselineCoverage: 10}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, gain := reward(tt.result)
+			if math.Abs(r-tt.reward) > 1e-9 || gain != tt.gain {
+				t.Errorf("reward = %v, %d, want %v, %d", r, gain, tt.reward, tt.gain)
+			}
+		})
+	}
+}
+
+func TestArmScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		plays      float64
+		rewards    float64
+		totalPlays float64
+		score      float64
+	}{
+		{"never played", 0, 0, 5, math.Inf(1)},
+		{"only arm played", 1, 0.5, 1, 0.5},
+		{"mean plus bonus", 2, 1, 8, 0.5 + exploration*math.Sqrt(2*math.Log(8)/2)},
+		{"less played arms get more bonus", 1, 0.5, 8, 0.5 + exploration*math.Sqrt(2*math.Log(8))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &arm{plays: tt.plays, rewards: tt.rewards}
+			if got := a.score(tt.totalPlays); math.Abs(got-tt.score) > 1e-9 && got != tt.score {
+				t.Errorf("score = %v, want %v", got, tt.score)
+			}
+		})
+	}
+}
+
+func TestArmUpdate(t *testing.T) {
+	a := &arm{}
+	a.decay()
+	a.play(1)
+	if a.plays != 1 || a.rewards != 1 {
+		t.Fatalf("after one round: plays %v, rewards %v", a.plays, a.rewards)
+	}
+
+	a.decay()
+	a.play(0)
+	if math.Abs(a.plays-(1*discount+1)) > 1e-9 || math.Abs(a.rewards-discount) > 1e-9 {
+		t.Errorf("after two rounds: plays %v, rewards %v", a.plays, a.rewards)
+	}
+
+	// A target that stops finding anything falls behind one that keeps going
+	stalled := &arm{plays: 1, rewards: 1}
+	steady := &arm{plays: 1, rewards: 0.5}
+	for range 10 {
+		stalled.decay()
+		steady.decay()
+		stalled.play(0)
+		steady.play(0.5)
+	}
+	if stalled.score(20) >= steady.score(20) {
+		t.Errorf("stalled arm scores %v, steady arm %v", stalled.score(20), steady.score(20))
+	}
+}
+
+func TestArmMerge(t *testing.T) {
+	a := &arm{}
+	a.merge(&Result{
+		Success:        true,
+		Duration:       10 * time.Second,
+		Execs:          100,
+		NewInteresting: 1,
+		Samples:        []Sample{{Elapsed: 5 * time.Second}},
+	})
+	a.merge(&Result{
+		Success:        false,
+		ErrorMessage:   "boom",
+		Duration:       10 * time.Second,
+		Execs:          300,
+		NewInteresting: 2,
+		Samples:        []Sample{{Elapsed: 5 * time.Second}},
+	})
+
+	r := a.result
+	if r.Rounds != 2 || r.Execs != 400 || r.NewInteresting != 3 || r.Duration != 20*time.Second {
+		t.Errorf("merged result: rounds %d, execs %d, interesting %d, duration %v",
+			r.Rounds, r.Execs, r.NewInteresting, r.Duration)
+	}
+	if r.ExecsPerSec != 20 {
+		t.Errorf("execs/s = %v, want 20", r.ExecsPerSec)
+	}
+	if r.Success || r.ErrorMessage != "boom" {
+		t.Errorf("failure of the second round wasn't kept: %v, %q", r.Success, r.ErrorMessage)
+	}
+	if len(r.Samples) != 2 || r.Samples[1].Elapsed != 15*time.Second {
+		t.Errorf("samples of later rounds aren't offset: %+v", r.Samples)
+	}
+}
@@ -0,0 +1,271 @@
+// internal/runner/binary.go
+package runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+// toolchainSettings are the go env variables that change how test binaries
+// are built without showing up in their sources
+var toolchainSettings = []string{
+	"GOVERSION", "GOOS", "GOARCH", "GOAMD64", "GOARM", "GOARM64",
+	"CGO_ENABLED", "CC", "GOEXPERIMENT", "GOFLAGS",
+}
+
+// binary is the fuzzing-instrumented test binary of a package
+type binary struct {
+	path string
+
+	// duration is how long it took to get the binary, including checking
+	// the cache, and cached whether it was already built by an earlier run
+	duration time.Duration
+	cached   bool
+
+	// err is set if the binary couldn't be built, with the go test output
+	// in output
+	err    error
+	output string
+
+	ready chan struct{}
+}
+
+// binaries builds the test binaries of fuzzed packages with go test -c,
+// once per package, and keeps them by package and source hash so later runs
+// can reuse them. It is safe for concurrent use.
+type binaries struct {
+	cfg *config.Config
+	dir string
+
+	mu     sync.Mutex
+	builds map[string]*binary
+
+	settingsMu sync.Mutex
+	settings   string
+}
+
+// newBinaries creates a binary cache in dir, or in a temporary directory
+// removed by the returned cleanup function if dir is empty
+func newBinaries(cfg *config.Config, dir string) (*binaries, func(), error) {
+	cleanup := func() {}
+	if dir == "" {
+		tempDir, err := os.MkdirTemp("", "fuzz-binaries-*")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create binary directory: %w", err)
+		}
+		dir = tempDir
+		cleanup = func() { os.RemoveAll(tempDir) }
+	}
+
+	b := &binaries{
+		cfg:    cfg,
+		dir:    dir,
+		builds: make(map[string]*binary),
+	}
+
+	return b, cleanup, nil
+}
+
+// get returns the test binary of a target's package, building it unless
+// another target of the package already did. Build failures are reported
+// in the binary; an error is only returned if ctx is cancelled.
+func (b *binaries) get(ctx context.Context, t *target.Target) (*binary, error) {
+	key := moduleDir(b.cfg, t) + " " + t.Package
+
+	b.mu.Lock()
+	bin, ok := b.builds[key]
+	if !ok {
+		bin = &binary{ready: make(chan struct{})}
+		b.builds[key] = bin
+	}
+	b.mu.Unlock()
+
+	if !ok {
+		b.build(ctx, t, key, bin)
+		close(bin.ready)
+	}
+
+	select {
+	case <-bin.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// A build stopped by an interrupt can be retried by the next run
+	if bin.err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return bin, nil
+}
+
+// build builds the test binary of a package, unless a binary of the same
+// sources is cached. Older binaries of the package are removed.
+func (b *binaries) build(ctx context.Context, t *target.Target, key string, bin *binary) {
+	start := time.Now()
+	defer func() { bin.duration = time.Since(start) }()
+
+	hash, err := b.sourceHash(ctx, t)
+	if err != nil {
+		bin.err = err
+		return
+	}
+
+	dir := filepath.Join(b.dir, fmt.Sprintf("%x", sha256.Sum256([]byte(key)))[:16])
+	bin.path = filepath.Join(dir, hash[:16]+".test")
+	if _, err := os.Stat(bin.path); err == nil {
+		bin.cached = true
+		return
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		bin.err = fmt.Errorf("failed to create binary directory: %w", err)
+		return
+	}
+
+	// Build next to the final path and rename it into place, so other runs
+	// never see a partial binary
+	tmp, err := os.CreateTemp(dir, ".build-*")
+	if err != nil {
+		bin.err = fmt.Errorf("failed to create binary: %w", err)
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	// -fuzz makes go test instrument the binary for coverage guidance; the
+	// fuzz target itself is chosen when the binary is run
+	cmd := buildCommand(ctx, b.cfg, moduleDir(b.cfg, t), "test",
+		"-c", "-fuzz", ".", "-o", tmp.Name(), t.Package)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		bin.err = fmt.Errorf("failed to build test binary: %w", err)
+		bin.output = string(output)
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), bin.path); err != nil {
+		bin.err = fmt.Errorf("failed to store test binary: %w", err)
+		return
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if name := entry.Name(); name != filepath.Base(bin.path) && !strings.HasPrefix(name, ".") {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+}
+
+// sourceHash hashes what the test binary of a target's package is built
+// from: the files of every package it depends on that isn't part of the
+// standard library or a fixed module version, the versions of the other
+// modules, the build tags and the toolchain settings
+func (b *binaries) sourceHash(ctx context.Context, t *target.Target) (string, error) {
+	settings, err := b.toolchainSettings(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := buildCommand(ctx, b.cfg, moduleDir(b.cfg, t), "list", "-deps", "-test",
+		"-json=ImportPath,Dir,Standard,Module,GoFiles,CgoFiles,CFiles,CXXFiles,HFiles,SFiles,SysoFiles,EmbedFiles,TestGoFiles,XTestGoFiles",
+		t.Package)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", settings, strings.Join(b.cfg.BuildTags, ","))
+
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var p struct {
+			ImportPath string
+			Dir        string
+			Standard   bool
+			Module     *struct {
+				Path    string
+				Version string
+				Main    bool
+				Replace *struct {
+					Path    string
+					Version string
+				}
+			}
+			GoFiles, CgoFiles, CFiles, CXXFiles, HFiles, SFiles, SysoFiles []string
+			EmbedFiles, TestGoFiles, XTestGoFiles                          []string
+		}
+		if err := dec.Decode(&p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("failed to parse go list output: %w", err)
+		}
+
+		// The generated test main package lives in the build cache and only
+		// depends on the test files hashed with the package
+		fmt.Fprintf(h, "%s\x00", p.ImportPath)
+		if p.Standard || strings.HasSuffix(p.ImportPath, ".test") {
+			continue
+		}
+
+		// Modules at a fixed version never change, but local replacements
+		// and the main modules do
+		if m := p.Module; m != nil && !m.Main && (m.Replace == nil || m.Replace.Version != "") {
+			fmt.Fprintf(h, "%s@%s\x00", m.Path, m.Version)
+			if m.Replace != nil {
+				fmt.Fprintf(h, "%s@%s\x00", m.Replace.Path, m.Replace.Version)
+			}
+			continue
+		}
+
+		files := slices.Concat(p.GoFiles, p.CgoFiles, p.CFiles, p.CXXFiles, p.HFiles, p.SFiles,
+			p.SysoFiles, p.EmbedFiles, p.TestGoFiles, p.XTestGoFiles)
+		for _, name := range files {
+			data, err := os.ReadFile(filepath.Join(p.Dir, name))
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", name, len(data))
+			h.Write(data)
+		}
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// toolchainSettings returns the go env settings binaries depend on, read
+// once per run
+func (b *binaries) toolchainSettings(ctx context.Context) (string, error) {
+	b.settingsMu.Lock()
+	defer b.settingsMu.Unlock()
+
+	if b.settings != "" {
+		return b.settings, nil
+	}
+
+	cmd := goCommand(ctx, b.cfg.RootDir, append([]string{"env"}, toolchainSettings...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go env failed: %w", err)
+	}
+
+	b.settings = string(output)
+	return b.settings, nil
+}
@@ -0,0 +1,158 @@
+// internal/runner/budget.go
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+// maxStopMargin caps the part of a budget kept free for stopping the last
+// running targets and collecting their results
+const maxStopMargin = 10 * time.Second
+
+// minAllotment is the shortest time worth starting a target for
+const minAllotment = time.Second
+
+// budget splits one wall-clock budget across all targets. Each target gets
+// a share of the time left proportional to its allocation weight when it
+// starts, and time left over by targets that finish early goes to the ones
+// still waiting or, once every target has started, to the running ones.
+type budget struct {
+	mu       sync.Mutex
+	deadline time.Time
+	lanes    int
+
+	// Number, weights and fixed durations of the targets that haven't
+	// started yet
+	pending       int
+	pendingWeight float64
+	pendingFixed  time.Duration
+
+	running map[*target.Target]*allotment
+}
+
+// allotment is the time given to a running target
+type allotment struct {
+	weight float64
+	start  time.Time
+	end    time.Time
+	timer  *time.Timer
+}
+
+// newBudget creates a budget for plans that are run on the given number of
+// lanes, starting now
+func newBudget(total time.Duration, lanes int, plans []*PlanEntry) *budget {
+	margin := min(total/20, maxStopMargin)
+
+	b := &budget{
+		deadline: time.Now().Add(total - margin),
+		lanes:    lanes,
+		running:  make(map[*target.Target]*allotment),
+	}
+	b.pending = len(plans)
+	for _, p := range plans {
+		if p.Override {
+			b.pendingFixed += p.Duration
+		} else {
+			b.pendingWeight += p.weight()
+		}
+	}
+
+	return b
+}
+
+// start allots time to a target that is about to run and calls stop once
+// the time is up. It returns false if too little of the budget is left to
+// start the target at all.
+func (b *budget) start(p *PlanEntry, stop context.CancelFunc) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending--
+	if p.Override {
+		b.pendingFixed -= p.Duration
+	} else {
+		b.pendingWeight -= p.weight()
+	}
+
+	now := time.Now()
+	remaining := b.deadline.Sub(now)
+
+	// The lane time left, minus what running targets and fixed-time targets
+	// still have to use, is shared among the waiting targets by weight
+	capacity := remaining*time.Duration(b.lanes) - b.pendingFixed
+	for _, a := range b.running {
+		capacity -= max(a.end.Sub(now), 0)
+	}
+
+	d := p.Duration
+	if !p.Override {
+		d = 0
+		if p.weight() > 0 {
+			d = time.Duration(float64(capacity) * p.weight() / (b.pendingWeight + p.weight()))
+		}
+	}
+	d = min(d, remaining)
+
+	if d < minAllotment {
+		return 0, false
+	}
+
+	b.running[p.Target] = &allotment{
+		weight: p.weight(),
+		start:  now,
+		end:    now.Add(d),
+		timer:  time.AfterFunc(d, stop),
+	}
+
+	return d, true
+}
+
+// finish releases the time a target didn't use. Once no target is waiting
+// anymore, it is handed to the targets still running. It returns the time
+// the target was allotted in the end.
+func (b *budget) finish(t *target.Target) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a, ok := b.running[t]
+	if !ok {
+		return 0
+	}
+	delete(b.running, t)
+	a.timer.Stop()
+
+	now := time.Now()
+	leftover := a.end.Sub(now)
+	if leftover <= 0 || b.pending > 0 {
+		return a.end.Sub(a.start)
+	}
+
+	// Targets whose time is already up are stopping and can't use more
+	var weights float64
+	for _, other := range b.running {
+		if other.end.After(now) {
+			weights += other.weight
+		}
+	}
+	if weights == 0 {
+		return a.end.Sub(a.start)
+	}
+
+	for _, other := range b.running {
+		if !other.end.After(now) {
+			continue
+		}
+		extra := time.Duration(float64(leftover) * other.weight / weights)
+		other.end = other.end.Add(extra)
+		if other.end.After(b.deadline) {
+			other.end = b.deadline
+		}
+		other.timer.Reset(other.end.Sub(now))
+	}
+
+	return a.end.Sub(a.start)
+}
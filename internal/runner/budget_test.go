@@ -0,0 +1,238 @@
+// internal/runner/budget_test.go
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+func TestSplitBudget(t *testing.T) {
+	tests := []struct {
+		name        string
+		budget      time.Duration
+		parallelism int
+		entries     []*PlanEntry
+		want        []time.Duration
+	}{
+		{
+			name:        "by weight on one lane",
+			budget:      time.Hour,
+			parallelism: 1,
+			entries:     []*PlanEntry{{Weight: 1}, {Weight: 3}},
+			want:        []time.Duration{15 * time.Minute, 45 * time.Minute},
+		},
+		{
+			name:        "lanes multiply the capacity",
+			budget:      time.Hour,
+			parallelism: 2,
+			entries:     []*PlanEntry{{Weight: 1}, {Weight: 1}},
+			want:        []time.Duration{time.Hour, time.Hour},
+		},
+		{
+			name:        "shares are capped by the budget",
+			budget:      time.Hour,
+			parallelism: 2,
+			entries:     []*PlanEntry{{Weight: 3}, {Weight: 1}},
+			want:        []time.Duration{time.Hour, 30 * time.Minute},
+		},
+		{
+			name:        "overrides are taken out first",
+			budget:      time.Hour,
+			parallelism: 1,
+			entries:     []*PlanEntry{{Duration: 20 * time.Minute, Override: true}, {Weight: 1}},
+			want:        []time.Duration{20 * time.Minute, 40 * time.Minute},
+		},
+		{
+			name:        "overrides can take the whole budget",
+			budget:      time.Hour,
+			parallelism: 1,
+			entries:     []*PlanEntry{{Duration: time.Hour, Override: true}, {Weight: 1}},
+			want:        []time.Duration{time.Hour, 0},
+		},
+		{
+			name:        "impact scales the weight",
+			budget:      time.Hour,
+			parallelism: 1,
+			entries:     []*PlanEntry{{Weight: 1, Impact: 0.5}, {Weight: 1, Impact: 1.5}},
+			want:        []time.Duration{15 * time.Minute, 45 * time.Minute},
+		},
+		{
+			name:        "zero weight gets nothing",
+			budget:      time.Hour,
+			parallelism: 1,
+			entries:     []*PlanEntry{{Weight: 0}, {Weight: 1}},
+			want:        []time.Duration{0, time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Budget: tt.budget, Parallelism: tt.parallelism}
+			splitBudget(cfg, tt.entries)
+
+			for i, entry := range tt.entries {
+				if entry.Duration != tt.want[i] {
+					t.Errorf("entry %d: duration = %v, want %v", i, entry.Duration, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanCapsOverrides(t *testing.T) {
+	targets := []*target.Target{
+		{Package: "example.com/p", Name: "FuzzConfigured"},
+		{Package: "example.com/p", Name: "FuzzAnnotated", Annotations: target.Annotations{FuzzTime: 3 * time.Hour}},
+		{Package: "example.com/p", Name: "FuzzShort", Annotations: target.Annotations{FuzzTime: time.Minute}},
+		{Package: "example.com/p", Name: "FuzzWeighted"},
+	}
+
+	tests := []struct {
+		name   string
+		budget time.Duration
+		want   []time.Duration
+	}{
+		{
+			name: "without a budget",
+			want: []time.Duration{2 * time.Hour, 3 * time.Hour, time.Minute, 10 * time.Minute},
+		},
+		{
+			// Four lanes hold 4h, of which capped overrides leave 1h59m
+			name:   "by the budget",
+			budget: time.Hour,
+			want:   []time.Duration{time.Hour, time.Hour, time.Minute, time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Parallelism = 4
+			cfg.FuzzTime = 10 * time.Minute
+			cfg.Budget = tt.budget
+			cfg.Targets = map[string]config.TargetConfig{"example.com/p.FuzzConfigured": {FuzzTime: 2 * time.Hour}}
+
+			plan := Plan(cfg, targets)
+			for i, entry := range plan {
+				if entry.Duration != tt.want[i] {
+					t.Errorf("%s: planned %v, want %v", entry.Target.Name, entry.Duration, tt.want[i])
+				}
+
+				// The budget takes the same fixed time out when the target
+				// starts as it set aside for it
+				if got := planTarget(cfg, entry.Target); entry.Override && got.Duration != entry.Duration {
+					t.Errorf("%s: started with %v, planned %v", entry.Target.Name, got.Duration, entry.Duration)
+				}
+			}
+		})
+	}
+}
+
+func TestBudgetMatchesPlan(t *testing.T) {
+	cfg := config.Default()
+	cfg.Parallelism = 2
+	cfg.Budget = time.Hour
+	cfg.Targets = map[string]config.TargetConfig{"example.com/p.FuzzA": {FuzzTime: 2 * time.Hour}}
+	targets := []*target.Target{
+		{Package: "example.com/p", Name: "FuzzA"},
+		{Package: "example.com/p", Name: "FuzzB"},
+	}
+
+	b := newBudget(cfg.Budget, 2, Plan(cfg, targets))
+	if b.pendingFixed != time.Hour {
+		t.Errorf("set aside %v for fixed times, want 1h", b.pendingFixed)
+	}
+
+	noop := func() {}
+	for _, tg := range targets {
+		if _, ok := b.start(planTarget(cfg, tg), noop); !ok {
+			t.Errorf("%s didn't start", tg.Name)
+		}
+	}
+	if b.pending != 0 || b.pendingFixed != 0 || b.pendingWeight != 0 {
+		t.Errorf("pending after every start: %d targets, %v fixed, %g weight", b.pending, b.pendingFixed, b.pendingWeight)
+	}
+
+	for _, tg := range targets {
+		b.finish(tg)
+	}
+}
+
+// near reports whether two durations differ by less than the time a test
+// may take between computing them
+func near(a, b time.Duration) bool {
+	return (a - b).Abs() < time.Second
+}
+
+func TestBudgetStart(t *testing.T) {
+	noop := func() {}
+	plans := []*PlanEntry{
+		{Target: &target.Target{Name: "FuzzA"}, Weight: 1},
+		{Target: &target.Target{Name: "FuzzB"}, Weight: 3},
+		{Target: &target.Target{Name: "FuzzC"}, Duration: 10 * time.Second, Override: true},
+	}
+
+	// 100s with a 5s stop margin, on one lane: 95s, of which 10s are fixed
+	b := newBudget(100*time.Second, 1, plans)
+
+	d, ok := b.start(plans[2], noop)
+	if !ok || d != 10*time.Second {
+		t.Fatalf("fixed target: %v, %v, want 10s", d, ok)
+	}
+
+	// The fixed target holds 10s, the rest is split 1:3
+	d, ok = b.start(plans[0], noop)
+	if !ok || !near(d, 85*time.Second/4) {
+		t.Errorf("first weighted target: %v, %v, want about %v", d, ok, 85*time.Second/4)
+	}
+	d, ok = b.start(plans[1], noop)
+	if !ok || !near(d, 85*time.Second*3/4) {
+		t.Errorf("second weighted target: %v, %v, want about %v", d, ok, 85*time.Second*3/4)
+	}
+
+	for _, p := range plans {
+		b.finish(p.Target)
+	}
+}
+
+func TestBudgetFinish(t *testing.T) {
+	noop := func() {}
+	plans := []*PlanEntry{
+		{Target: &target.Target{Name: "FuzzA"}, Weight: 1},
+		{Target: &target.Target{Name: "FuzzB"}, Weight: 1},
+		{Target: &target.Target{Name: "FuzzC"}, Weight: 1},
+	}
+	b := newBudget(120*time.Second, 2, plans)
+
+	a, _ := b.start(plans[0], noop)
+	b.start(plans[1], noop)
+
+	// While a target is waiting, time left over isn't handed out
+	if got := b.finish(plans[0].Target); !near(got, a) {
+		t.Errorf("finished target kept %v, want %v", got, a)
+	}
+	before := b.running[plans[1].Target].end
+
+	c, ok := b.start(plans[2], noop)
+	if !ok {
+		t.Fatal("last target didn't start")
+	}
+
+	// Once every target has started, the leftover goes to the running ones
+	b.finish(plans[2].Target)
+	after := b.running[plans[1].Target].end
+	if !near(after.Sub(before), c) && !after.Equal(b.deadline) {
+		t.Errorf("running target got %v more, want %v or up to the deadline", after.Sub(before), c)
+	}
+	if after.After(b.deadline) {
+		t.Errorf("running target ends %v after the deadline", after.Sub(b.deadline))
+	}
+
+	b.finish(plans[1].Target)
+	if got := b.finish(plans[1].Target); got != 0 {
+		t.Errorf("finishing twice returned %v", got)
+	}
+}
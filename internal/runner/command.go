@@ -0,0 +1,59 @@
+// internal/runner/command.go
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+// shutdownGracePeriod is how long a go command may take to exit after being
+// interrupted before it is killed
+const shutdownGracePeriod = 30 * time.Second
+
+// goCommand builds a go command that is interrupted, rather than killed,
+// when ctx is cancelled. This lets the fuzzer flush its corpus and report
+// any failing input before exiting.
+func goCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	return interruptibleCommand(ctx, dir, "go", args...)
+}
+
+// interruptibleCommand builds a command, such as a test binary, that is
+// interrupted like goCommand when ctx is cancelled
+func interruptibleCommand(ctx context.Context, dir, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return interruptProcess(cmd)
+	}
+	cmd.WaitDelay = shutdownGracePeriod
+
+	return cmd
+}
+
+// buildCommand builds a go command, such as go test, that compiles packages
+// and therefore needs the configured build tags. It runs in dir, which must
+// be the directory of the module the packages belong to unless they are part
+// of a workspace.
+func buildCommand(ctx context.Context, cfg *config.Config, dir, subcommand string, args ...string) *exec.Cmd {
+	flags := []string{subcommand}
+	if len(cfg.BuildTags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(cfg.BuildTags, ","))
+	}
+
+	return goCommand(ctx, dir, append(flags, args...)...)
+}
+
+// moduleDir returns the directory go commands for a target run in
+func moduleDir(cfg *config.Config, t *target.Target) string {
+	if t.ModuleDir != "" {
+		return t.ModuleDir
+	}
+	return cfg.RootDir
+}
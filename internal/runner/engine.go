@@ -2,15 +2,17 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/OmBiradar/go-fuzz-runner/internal/corpus"
+	"github.com/OmBiradar/go-fuzz-runner/internal/crash"
 	"github.com/OmBiradar/go-fuzz-runner/internal/target"
 	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
 )
@@ -23,148 +25,396 @@ type Result struct {
 	ErrorMessage   string
 	CrashInputs    []string
 	NewCorpusItems int
-	Coverage       float64
+	Interrupted    bool
+
+	// Allotted is the share of the time budget the target got, including
+	// time handed over by targets that finished early
+	Allotted time.Duration
+
+	// Rounds is the number of rounds the target ran for with the adaptive
+	// strategy
+	Rounds int
+
+	// BuildDuration is how long it took to get the package's test binary,
+	// which is shared by all targets of the package. It isn't part of
+	// Duration.
+	BuildDuration time.Duration
+	BinaryCached  bool
+
+	// Crash is the failing input found during the run, if any, and
+	// CrashBucket the bucket of known crashes it was filed into
+	Crash       *crash.Crash
+	CrashBucket *crash.Bucket
+
+	// Coverage is the number of coverage counters hit at the end of the run,
+	// BaselineCoverage the number hit by the corpus before fuzzing started
+	Coverage         float64
+	BaselineCoverage float64
+
+	// Totals from the last progress line reported by the fuzzer
+	Execs          int64
+	ExecsPerSec    float64
+	NewInteresting int
+
+	// Samples is the progress time series of the run
+	Samples []Sample
 }
 
+// ProgressFunc receives live progress samples from running targets
+type ProgressFunc func(t *target.Target, s Sample)
+
 // FuzzEngine handles the execution of fuzz tests
 type FuzzEngine struct {
 	Config        *config.Config
 	Targets       []*target.Target
 	CorpusManager *corpus.CorpusManager
+	CrashStore    *crash.Store
 	Results       []*Result
+
+	// Skipped lists the targets that were not started because the time
+	// budget ran out or their time allocation is zero
+	Skipped []*target.Target
+
+	// Rounds records the decisions of the adaptive strategy
+	Rounds []*Round
+
+	// Progress, if set, is called for every progress line of every target.
+	// It may be called concurrently.
+	Progress ProgressFunc
+
+	// BinaryCacheDir is where test binaries are kept across runs. If empty,
+	// they are built into a temporary directory for every run.
+	BinaryCacheDir string
+
+	// BuildDuration is how long building the test binaries took before
+	// fuzzing started
+	BuildDuration time.Duration
+
+	startedAt time.Time
+	budget    *budget
+	binaries  *binaries
 }
 
 // NewFuzzEngine creates a new fuzzing engine
-func NewFuzzEngine(cfg *config.Config, targets []*target.Target) (*FuzzEngine, error) {
-	cm, err := corpus.NewCorpusManager(cfg.CorpusDir, corpus.CoverageMinimization)
+func NewFuzzEngine(ctx context.Context, cfg *config.Config, targets []*target.Target) (*FuzzEngine, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cm, err := corpus.NewCorpusManager(cfg.CorpusDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create corpus manager: %w", err)
 	}
 
+	cs, err := crash.NewStore(cfg.CrashPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create crash store: %w", err)
+	}
+	cs.MatchLines = cfg.CrashSignatureLines
+
 	return &FuzzEngine{
 		Config:        cfg,
 		Targets:       targets,
 		CorpusManager: cm,
+		CrashStore:    cs,
 	}, nil
 }
 
-// RunAll runs all fuzz targets
-func (e *FuzzEngine) RunAll() error {
-	for _, target := range e.Targets {
-		result, err := e.RunTarget(target)
+// RunAll runs all fuzz targets, several at a time when the parallelism
+// budget allows it. Results are reported in target order. When ctx is
+// cancelled, no further targets are started, running ones are stopped
+// gracefully and the partial results are kept.
+func (e *FuzzEngine) RunAll(ctx context.Context) error {
+	e.startedAt = time.Now()
+
+	// Corpus entries are recorded as found by the run of the report
+	e.CorpusManager.RunID = runID(e.startedAt)
+
+	cleanup, err := e.setupBinaries()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Build all test binaries up front, so the time left for fuzzing is
+	// known and no target's time is spent building
+	if err := e.prebuild(ctx); err != nil {
+		return err
+	}
+	e.BuildDuration = time.Since(e.startedAt)
+
+	if e.Config.Strategy == config.StrategyAdaptive {
+		return e.runAdaptive(ctx)
+	}
+
+	// With a total budget, targets share what is left of it instead of
+	// getting a fixed time
+	if e.Config.Budget > 0 {
+		lanes := planLanes(e.Config.Parallelism, e.Config.ConcurrentTargets, len(e.Targets))
+		e.budget = newBudget(max(e.Config.Budget-e.BuildDuration, 0), len(lanes), Plan(e.Config, e.Targets))
+		defer func() { e.budget = nil }()
+	}
+
+	results := make([]*Result, len(e.Targets))
+	err = e.schedule(ctx, func(i, workers int) error {
+		result, err := e.runTarget(ctx, e.Targets[i], workers)
 		if err != nil {
-			return fmt.Errorf("failed to run target %s.%s: %w",
-				target.Package, target.Name, err)
+			return err
 		}
 
-		e.Results = append(e.Results, result)
+		results[i] = result
+		return nil
+	})
+
+	for i, result := range results {
+		if result != nil {
+			e.Results = append(e.Results, result)
+		} else if err == nil {
+			e.Skipped = append(e.Skipped, e.Targets[i])
+		}
 	}
 
-	return nil
+	return err
+}
+
+// RunTarget runs a single fuzz target using the whole parallelism budget
+func (e *FuzzEngine) RunTarget(ctx context.Context, t *target.Target) (*Result, error) {
+	cleanup, err := e.setupBinaries()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return e.runTarget(ctx, t, e.Config.Parallelism)
+}
+
+// setupBinaries prepares the test binaries of a run. The returned function
+// cleans up after it.
+func (e *FuzzEngine) setupBinaries() (func(), error) {
+	b, cleanup, err := newBinaries(e.Config, e.BinaryCacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	e.binaries = b
+	return func() {
+		cleanup()
+		e.binaries = nil
+	}, nil
+}
+
+// prebuild builds the test binaries of all targets' packages, as many at a
+// time as there are parallel processes. Build failures are reported by the
+// targets when they run.
+func (e *FuzzEngine) prebuild(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, max(e.Config.Parallelism, 1))
+		seen = make(map[string]bool)
+	)
+	for _, t := range e.Targets {
+		key := moduleDir(e.Config, t) + " " + t.Package
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			e.binaries.get(ctx, t)
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
 }
 
-// RunTarget runs a single fuzz target
-func (e *FuzzEngine) RunTarget(t *target.Target) (*Result, error) {
+// runTarget runs a single fuzz target with the given number of fuzz workers.
+// It returns a nil result if the time budget doesn't allow running it.
+func (e *FuzzEngine) runTarget(ctx context.Context, t *target.Target, workers int) (*Result, error) {
 	result := &Result{
 		Target: t,
 	}
 
+	// Get the duration for this target based on time allocation. With a
+	// budget, the target runs until its share is used up, which may grow
+	// while it runs, so it is stopped by cancelling runCtx instead.
+	runCtx := ctx
+	targetTime := e.getTargetDuration(t)
+	if e.budget != nil {
+		var stop context.CancelFunc
+		runCtx, stop = context.WithCancel(ctx)
+		defer stop()
+
+		allotted, ok := e.budget.start(planTarget(e.Config, t), stop)
+		if !ok {
+			return nil, nil
+		}
+		defer e.budget.finish(t)
+
+		result.Allotted = allotted
+		targetTime = time.Until(e.budget.deadline)
+	}
+
+	if err := e.fuzz(ctx, runCtx, t, workers, targetTime, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// fuzz runs the fuzzer on a target for up to fuzzTime, or until runCtx is
+// cancelled, and records the outcome in result. Only the cancellation of
+// ctx counts as an interruption.
+func (e *FuzzEngine) fuzz(ctx, runCtx context.Context, t *target.Target, workers int, fuzzTime time.Duration, result *Result) error {
 	// Create a temporary directory for this run
 	tempDir, err := os.MkdirTemp("", "fuzz-run-*")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Copy corpus to temp directory
+	// Copy corpus to temp directory. The fuzzer reads and extends its cache
+	// corpus in <fuzzcachedir>/<FuzzName>, so the entries are placed there.
 	corpusDir := e.CorpusManager.GetTargetDir(t)
-	tempCorpusDir := filepath.Join(tempDir, "corpus")
+	cacheDir := filepath.Join(tempDir, "cache")
+	tempCorpusDir := filepath.Join(cacheDir, t.Name)
 	if err := os.MkdirAll(tempCorpusDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create temp corpus directory: %w", err)
+		return fmt.Errorf("failed to create temp corpus directory: %w", err)
 	}
 
 	// Copy existing corpus entries
 	if err := copyDir(corpusDir, tempCorpusDir); err != nil {
-		return nil, fmt.Errorf("failed to copy corpus: %w", err)
+		return fmt.Errorf("failed to copy corpus: %w", err)
 	}
 
-	// Get the duration for this target based on time allocation
-	targetTime := e.getTargetDuration(t)
-
-	// Create crashers directory
-	crashersDir := filepath.Join(tempDir, "crashers")
-	if err := os.MkdirAll(crashersDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create crashers directory: %w", err)
+	bin, err := e.binaries.get(ctx, t)
+	if err != nil {
+		return err
+	}
+	result.BuildDuration = bin.duration
+	result.BinaryCached = bin.cached
+	if bin.err != nil {
+		result.Success = false
+		result.ErrorMessage = fmt.Sprintf("%v\n%s", bin.err, bin.output)
+		return nil
 	}
 
-	// Run the fuzz test
+	// Run the fuzz test. Like go test, the binary runs in the package
+	// directory, where the fuzzer reads and writes testdata/fuzz.
 	start := time.Now()
-	cmd := exec.Command("go", "test",
-		"-run", "^$", // Don't run regular tests
-		"-fuzz", t.Name,
-		"-fuzztime", targetTime.String(),
-		"-parallel", fmt.Sprintf("%d", e.Config.Parallelism),
-		t.Package)
-
-	// Set environment variables for corpus and cache
-	cmd.Env = append(os.Environ(),
-		fmt.Sprintf("GOFUZZCACHE=%s", tempDir),
-		fmt.Sprintf("GOPATH=%s", os.Getenv("GOPATH")))
-
-	// Capture output
-	output, err := cmd.CombinedOutput()
+	cmd := interruptibleCommand(runCtx, filepath.Dir(t.FilePath), bin.path,
+		"-test.run", "^$", // Don't run regular tests
+		"-test.fuzz", fmt.Sprintf("^%s$", t.Name),
+		"-test.fuzztime", fuzzTime.String(),
+		"-test.parallel", fmt.Sprintf("%d", workers),
+		"-test.fuzzcachedir", cacheDir)
+
+	cmd.Env = withFuzzDebug(os.Environ())
+
+	// Stream output, turning progress lines into samples as they arrive
+	var progress progressParser
+	output, err := runStreaming(cmd, func(line string) {
+		if s, ok := progress.parseLine(line); ok && e.Progress != nil {
+			e.Progress(t, s)
+		}
+	})
 
 	// Calculate actual duration
 	result.Duration = time.Since(start)
 
+	// Hand the unused part of the budget share to the other targets
+	if e.budget != nil {
+		result.Allotted = e.budget.finish(t)
+	}
+
+	// An interrupted fuzzer exits non-zero even though it stopped cleanly,
+	// so only a reported test failure counts as a failure in that case.
+	// Being stopped at the end of a budget share is not an interruption.
+	if runCtx.Err() != nil {
+		result.Interrupted = ctx.Err() != nil
+		if !strings.Contains(output, "--- FAIL") {
+			err = nil
+		}
+	}
+
 	// Check for failures
 	if err != nil {
 		result.Success = false
-		result.ErrorMessage = string(output)
-
-		// Look for crash inputs
-		crashers, errCrash := filepath.Glob(filepath.Join(tempDir, "crashers", "*"))
-		if errCrash == nil {
-			for _, crasher := range crashers {
-				if strings.HasSuffix(crasher, ".output") {
-					continue
-				}
-				result.CrashInputs = append(result.CrashInputs, crasher)
-			}
+		result.ErrorMessage = output
+
+		// Move the failing input written by the fuzzer into the crash store
+		if err := e.collectCrash(t, result, output); err != nil {
+			return err
 		}
 	} else {
 		result.Success = true
 	}
 
 	// Import new corpus entries found during this run
-	newCorpusDir := filepath.Join(tempDir, "corpus")
-	if err := e.CorpusManager.ImportNewCorpusEntries(t, newCorpusDir); err != nil {
-		return nil, fmt.Errorf("failed to import new corpus entries: %w", err)
+	added, err := e.CorpusManager.ImportNewCorpusEntries(t, tempCorpusDir)
+	if err != nil {
+		return fmt.Errorf("failed to import new corpus entries: %w", err)
+	}
+	result.NewCorpusItems = added
+
+	// Record progress metrics
+	result.Samples = progress.samples
+	result.BaselineCoverage = float64(progress.baselineCoverage)
+	result.Coverage = float64(progress.coverageBits)
+	if last, ok := progress.last(); ok {
+		result.Execs = last.Execs
+		result.NewInteresting = last.NewInteresting
+		if last.Elapsed > 0 {
+			result.ExecsPerSec = float64(last.Execs) / last.Elapsed.Seconds()
+		}
 	}
 
-	// Count new corpus items (simplified)
-	entries, _ := os.ReadDir(newCorpusDir)
-	result.NewCorpusItems = len(entries)
+	return nil
+}
 
-	// Parse coverage information
-	// This would require parsing the output to extract coverage info
-	// For brevity, we're not implementing the full coverage extraction
+// collectCrash stores the failing input reported in the output of a failed
+// run. The fuzzer writes it to testdata/fuzz/<FuzzName> in the package
+// directory; it is moved out of the source tree so later runs of the target
+// keep fuzzing instead of failing on the seed corpus.
+func (e *FuzzEngine) collectCrash(t *target.Target, result *Result, output string) error {
+	inputPath, ok := crash.FailingInput(output, filepath.Dir(t.FilePath))
+	if !ok {
+		return nil
+	}
 
-	return result, nil
+	c, b, err := e.CrashStore.Save(t, inputPath, crash.FailureText(output))
+	if err != nil {
+		return fmt.Errorf("failed to store crash: %w", err)
+	}
+	// Remove the input and any directories the fuzzer created for it;
+	// os.Remove leaves non-empty directories alone
+	os.Remove(inputPath)
+	stagedDirs.Lock()
+	for dir := filepath.Dir(inputPath); filepath.Base(dir) != "testdata"; dir = filepath.Dir(dir) {
+		if stagedDirs.users[dir] > 0 || os.Remove(dir) != nil {
+			break
+		}
+	}
+	if testdata := filepath.Join(filepath.Dir(t.FilePath), "testdata"); stagedDirs.users[testdata] == 0 {
+		os.Remove(testdata)
+	}
+	stagedDirs.Unlock()
+
+	result.Crash = c
+	result.CrashBucket = b
+	result.CrashInputs = append(result.CrashInputs, c.InputPath)
+
+	return nil
 }
 
 // getTargetDuration calculates how much time to spend on a target
 func (e *FuzzEngine) getTargetDuration(t *target.Target) time.Duration {
-	totalTime := e.Config.FuzzTime
-
-	// Check if there's a specific allocation for this package
-	packageName := t.Package
-	if allocation, ok := e.Config.TimeAllocation[packageName]; ok {
-		return time.Duration(float64(totalTime) * allocation)
-	}
-
-	// Use default allocation
-	return time.Duration(float64(totalTime) * e.Config.TimeAllocation["default"])
+	return planTarget(e.Config, t).Duration
 }
 
 // copyDir copies a directory recursively
@@ -0,0 +1,308 @@
+// internal/runner/minimize.go
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/cover"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/corpus"
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+// MinimizeResult is the outcome of minimizing the corpus of a target.
+// Coverage is counted in features, pairs of a covered code block and the
+// bucket of its hit count.
+type MinimizeResult struct {
+	Target *target.Target
+
+	Entries     int
+	Kept        int
+	Bytes       int64
+	KeptBytes   int64
+	Coverage    int
+	KeptCovered int
+
+	// Failing are the hashes of entries that fail when replayed. They are
+	// kept, as their coverage is unknown.
+	Failing []string
+
+	Duration time.Duration
+}
+
+// MinimizeTarget reduces the corpus of a target to a small subset of entries
+// with the same coverage. Every entry is replayed on its own through a
+// coverage-instrumented test binary, and the entries are selected by
+// corpus.SelectCover. With dryRun set, nothing is removed.
+//
+// The fuzzer's own edge counters can't be read from outside of go test, so
+// coverage is approximated with go's count-mode block coverage, bucketed
+// the way fuzzers bucket their counters: an entry that runs a block 5 times
+// covers something an entry running it once doesn't. Blocks are coarser
+// than edges, so an entry the fuzzer found interesting may still go.
+func (e *FuzzEngine) MinimizeTarget(ctx context.Context, t *target.Target, dryRun bool) (*MinimizeResult, error) {
+	start := time.Now()
+	result := &MinimizeResult{Target: t}
+
+	idx, err := e.CorpusManager.Index(t)
+	if err != nil {
+		return nil, err
+	}
+	result.Entries = len(idx.Entries)
+	result.Bytes = idx.Size()
+	if len(idx.Entries) == 0 {
+		return result, nil
+	}
+
+	tempDir, err := os.MkdirTemp("", "fuzz-minimize-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	bin, err := e.buildCoverBinary(ctx, t, tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := e.CorpusManager.GetTargetDir(t)
+	inputs := make([]string, len(idx.Entries))
+	for i, entry := range idx.Entries {
+		inputs[i] = filepath.Join(dir, entry.Hash)
+	}
+
+	pkgDir := filepath.Dir(t.FilePath)
+	stage, err := stageSeeds(pkgDir, t.Name, inputs)
+	if err != nil {
+		return nil, err
+	}
+	defer stage.Cleanup()
+
+	// A malformed entry fails every run of the target, so those are taken
+	// out first
+	malformed, err := malformedEntries(ctx, t, bin, pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	var replayed []*corpus.Entry
+	for _, entry := range idx.Entries {
+		if slices.Contains(malformed, entry.Hash) {
+			stage.Remove(entry.Hash)
+		} else {
+			replayed = append(replayed, entry)
+		}
+	}
+
+	coverage, failing, err := e.replayCoverage(ctx, t, bin, pkgDir, tempDir, replayed)
+	if err != nil {
+		return nil, err
+	}
+	result.Failing = append(malformed, failing...)
+
+	selected := corpus.SelectCover(idx.Entries, coverage)
+	keep := make(map[string]bool)
+	for _, entry := range selected {
+		keep[entry.Hash] = true
+	}
+	for _, hash := range result.Failing {
+		keep[hash] = true
+	}
+
+	all := make(map[string]bool)
+	kept := make(map[string]bool)
+	var remove []string
+	for _, entry := range idx.Entries {
+		for _, f := range coverage[entry.Hash] {
+			all[f] = true
+			if keep[entry.Hash] {
+				kept[f] = true
+			}
+		}
+
+		if keep[entry.Hash] {
+			result.Kept++
+			result.KeptBytes += entry.Size
+		} else {
+			remove = append(remove, entry.Hash)
+		}
+	}
+	result.Coverage = len(all)
+	result.KeptCovered = len(kept)
+
+	// Test files aren't instrumented, so a target that only exercises code
+	// in them would lose its whole corpus
+	if result.Coverage == 0 {
+		return nil, fmt.Errorf("no coverage measured outside of test files, leaving the corpus as it is")
+	}
+
+	if !dryRun {
+		if err := e.CorpusManager.Remove(t, remove); err != nil {
+			return nil, fmt.Errorf("failed to remove corpus entries: %w", err)
+		}
+	}
+
+	result.Duration = time.Since(start)
+
+	return result, nil
+}
+
+// buildCoverBinary builds the test binary of a target's package with
+// hit-counting coverage instrumentation of all packages of its module
+func (e *FuzzEngine) buildCoverBinary(ctx context.Context, t *target.Target, dir string) (string, error) {
+	coverPkg := t.Package
+	if t.Module != "" {
+		coverPkg = t.Module + "/..."
+	}
+
+	bin := filepath.Join(dir, "cover.test")
+	cmd := buildCommand(ctx, e.Config, moduleDir(e.Config, t), "test",
+		"-c", "-cover", "-covermode", "count", "-coverpkg", coverPkg,
+		"-o", bin, t.Package)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("failed to build coverage binary: %w\n%s", err, output)
+	}
+
+	return bin, nil
+}
+
+// malformedEntries returns the names of the seeds of a target that go test
+// can't read, from the errors it reports for them, e.g.
+// "testdata/fuzz/FuzzX/5d383a64e88cd990": unmarshal: ...
+func malformedEntries(ctx context.Context, t *target.Target, bin, pkgDir string) ([]string, error) {
+	// The seed corpus is read even if none of the seeds are run
+	cmd := interruptibleCommand(ctx, pkgDir, bin, "-test.run", fmt.Sprintf("^%s$/^$", t.Name))
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err == nil {
+		return nil, nil
+	}
+
+	line := regexp.MustCompile(fmt.Sprintf(`"[^"]*testdata[/\\]+fuzz[/\\]+%s[/\\]+([^"/\\]+)": `, regexp.QuoteMeta(t.Name)))
+	var malformed []string
+	for _, m := range line.FindAllStringSubmatch(string(output), -1) {
+		malformed = append(malformed, m[1])
+	}
+	if len(malformed) == 0 {
+		return nil, fmt.Errorf("test binary failed without running any input: %w\n%s", err, output)
+	}
+
+	return malformed, nil
+}
+
+// replayCoverage runs every entry on its own as a seed of the target, as many
+// at a time as there are parallel processes, and returns the coverage features
+// each one covers. Entries that fail are returned separately.
+func (e *FuzzEngine) replayCoverage(ctx context.Context, t *target.Target, bin, pkgDir, tempDir string, entries []*corpus.Entry) (map[string][]string, []string, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		coverage = make(map[string][]string)
+		failing  []string
+		errs     []error
+		sem      = make(chan struct{}, max(e.Config.Parallelism, 1))
+	)
+	for _, entry := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			features, ok, err := e.entryCoverage(ctx, t, bin, pkgDir, tempDir, entry.Hash)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				errs = append(errs, err)
+			case !ok:
+				failing = append(failing, entry.Hash)
+			default:
+				coverage[entry.Hash] = features
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	if len(errs) > 0 {
+		return nil, nil, errs[0]
+	}
+
+	return coverage, failing, nil
+}
+
+// entryCoverage replays a single staged entry and returns the coverage
+// features it hits, each a code block with the bucket of its hit count, or
+// false if it fails
+func (e *FuzzEngine) entryCoverage(ctx context.Context, t *target.Target, bin, pkgDir, tempDir, hash string) ([]string, bool, error) {
+	profile := filepath.Join(tempDir, hash+".cover")
+	cmd := interruptibleCommand(ctx, pkgDir, bin,
+		"-test.run", fmt.Sprintf("^%s$/^%s$", t.Name, hash),
+		"-test.v",
+		"-test.coverprofile", profile)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+	if err != nil {
+		return nil, false, nil
+	}
+
+	// A seed that doesn't run would look like an input without coverage
+	if !strings.Contains(string(output), fmt.Sprintf("--- PASS: %s/%s ", t.Name, hash)) {
+		return nil, false, fmt.Errorf("corpus entry %s of %s.%s wasn't replayed:\n%s", hash, t.Package, t.Name, output)
+	}
+
+	profiles, err := cover.ParseProfiles(profile)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse coverage profile: %w", err)
+	}
+
+	var features []string
+	for _, p := range profiles {
+		for _, b := range p.Blocks {
+			if b.Count > 0 {
+				features = append(features, fmt.Sprintf("%s:%d.%d,%d.%d#%d",
+					p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, hitBucket(b.Count)))
+			}
+		}
+	}
+
+	return features, true, nil
+}
+
+// hitBucket returns the lower bound of the range a hit count falls into.
+// Like the counters of libFuzzer and the Go fuzzer, 1, 2 and 3 hits are
+// told apart, larger counts only by their order of magnitude.
+func hitBucket(count int) int {
+	switch {
+	case count <= 3:
+		return count
+	case count <= 7:
+		return 4
+	case count <= 15:
+		return 8
+	case count <= 31:
+		return 16
+	case count <= 127:
+		return 32
+	}
+	return 128
+}
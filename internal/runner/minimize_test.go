@@ -0,0 +1,23 @@
+// internal/runner/minimize_test.go
+package runner
+
+import "testing"
+
+func TestHitBucket(t *testing.T) {
+	tests := []struct {
+		count, bucket int
+	}{
+		{1, 1}, {2, 2}, {3, 3},
+		{4, 4}, {7, 4},
+		{8, 8}, {15, 8},
+		{16, 16}, {31, 16},
+		{32, 32}, {127, 32},
+		{128, 128}, {100000, 128},
+	}
+
+	for _, tt := range tests {
+		if got := hitBucket(tt.count); got != tt.bucket {
+			t.Errorf("hitBucket(%d) = %d, want %d", tt.count, got, tt.bucket)
+		}
+	}
+}
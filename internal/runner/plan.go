@@ -0,0 +1,110 @@
+// internal/runner/plan.go
+package runner
+
+import (
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+// PlanEntry describes how much time a target will be fuzzed for and why
+type PlanEntry struct {
+	Target   *target.Target
+	Duration time.Duration
+
+	// Override is set when a per-target fuzz time replaces the allocation,
+	// Annotated when it comes from a //fuzz:time annotation rather than the
+	// configuration
+	Override  bool
+	Annotated bool
+
+	// Key is the time allocation key that matched, empty if none did, and
+	// Weight the fraction of the fuzz time it assigns
+	Key    string
+	Weight float64
+
+	// Impact scales the weight by the target's share of the changed code,
+	// zero without function-level impact analysis
+	Impact float64
+}
+
+// weight returns the allocation weight scaled by the change impact
+func (p *PlanEntry) weight() float64 {
+	if p.Impact > 0 {
+		return p.Weight * p.Impact
+	}
+	return p.Weight
+}
+
+// Plan returns the time each target will be fuzzed for. With a time budget
+// the durations are estimates, as the real split depends on how long each
+// target ends up running.
+func Plan(cfg *config.Config, targets []*target.Target) []*PlanEntry {
+	entries := make([]*PlanEntry, len(targets))
+	for i, t := range targets {
+		entries[i] = planTarget(cfg, t)
+	}
+
+	if cfg.Budget > 0 {
+		splitBudget(cfg, entries)
+	}
+
+	return entries
+}
+
+// splitBudget divides the lane time of a budget among the weighted entries,
+// the way it is divided when every target uses its full share. The fixed
+// times of overrides are already capped by the budget.
+func splitBudget(cfg *config.Config, entries []*PlanEntry) {
+	lanes := planLanes(cfg.Parallelism, cfg.ConcurrentTargets, len(entries))
+	capacity := cfg.Budget * time.Duration(len(lanes))
+
+	var weights float64
+	for _, entry := range entries {
+		if entry.Override {
+			capacity -= entry.Duration
+		} else {
+			weights += entry.weight()
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Override {
+			continue
+		}
+
+		entry.Duration = 0
+		if entry.weight() > 0 && capacity > 0 {
+			share := time.Duration(float64(capacity) * entry.weight() / weights)
+			entry.Duration = min(share, cfg.Budget)
+		}
+	}
+}
+
+// planTarget works out the fuzz time of a single target. A fixed time is
+// capped by the budget, if there is one, as no target can run any longer.
+func planTarget(cfg *config.Config, t *target.Target) *PlanEntry {
+	var entry *PlanEntry
+
+	// A configured per-target time takes precedence over an annotated one,
+	// which takes precedence over the allocation
+	if tc, ok := cfg.Target(t.Package, t.Name); ok && tc.FuzzTime > 0 {
+		entry = &PlanEntry{Target: t, Duration: tc.FuzzTime, Override: true}
+	} else if t.Annotations.FuzzTime > 0 {
+		entry = &PlanEntry{Target: t, Duration: t.Annotations.FuzzTime, Override: true, Annotated: true}
+	} else {
+		key, weight := cfg.Allocation(t.Package, t.Name)
+		entry = &PlanEntry{Target: t, Key: key, Weight: weight}
+		if t.Impact != nil {
+			entry.Impact = t.Impact.Share
+		}
+		entry.Duration = time.Duration(float64(cfg.FuzzTime) * entry.weight())
+	}
+
+	if entry.Override && cfg.Budget > 0 {
+		entry.Duration = min(entry.Duration, cfg.Budget)
+	}
+
+	return entry
+}
@@ -0,0 +1,16 @@
+// internal/runner/proc_other.go
+
+//go:build !unix
+
+package runner
+
+import "os/exec"
+
+// setProcessGroup is a no-op on platforms without process groups
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// interruptProcess kills the command, as interrupts cannot be delivered
+// to another process on this platform
+func interruptProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
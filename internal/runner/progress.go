@@ -0,0 +1,164 @@
+// internal/runner/progress.go
+package runner
+
+import (
+	"bufio"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fuzzing phases reported in progress samples
+const (
+	PhaseBaseline   = "baseline"
+	PhaseFuzzing    = "fuzzing"
+	PhaseMinimizing = "minimizing"
+)
+
+// Sample is a point-in-time snapshot of fuzzing progress for one target
+type Sample struct {
+	Elapsed          time.Duration `json:"elapsed"`
+	Phase            string        `json:"phase"`
+	Execs            int64         `json:"execs"`
+	ExecsPerSec      float64       `json:"execs_per_sec"`
+	NewInteresting   int           `json:"new_interesting"`
+	TotalInteresting int           `json:"total_interesting"`
+	BaselineDone     int           `json:"baseline_done,omitempty"`
+	BaselineTotal    int           `json:"baseline_total,omitempty"`
+	CoverageBits     int           `json:"coverage_bits"`
+}
+
+var (
+	// fuzz: elapsed: 3s, execs: 98629 (32836/sec), new interesting: 3 (total: 4)
+	execsLine = regexp.MustCompile(`^fuzz: elapsed: (\S+), execs: (\d+) \(([\d.]+)/sec\)(?:, new interesting: (\d+) \(total: (\d+)\))?`)
+
+	// fuzz: elapsed: 0s, gathering baseline coverage: 1/1 completed, now fuzzing with 2 workers
+	baselineLine = regexp.MustCompile(`^fuzz: elapsed: (\S+), (?:gathering baseline coverage|testing seed corpus): (\d+)/(\d+) completed`)
+
+	// fuzz: elapsed: 5s, minimizing
+	minimizingLine = regexp.MustCompile(`^fuzz: elapsed: (\S+), minimizing`)
+
+	// Emitted with GODEBUG=fuzzdebug=1
+	initialCoverageLine = regexp.MustCompile(`DEBUG finished processing input corpus, entries: \d+, initial coverage bits: (\d+)`)
+	totalCoverageLine   = regexp.MustCompile(`DEBUG new interesting input, .* total bits: (\d+)`)
+)
+
+// progressParser turns go test fuzzing output into progress samples
+type progressParser struct {
+	baselineCoverage int
+	coverageBits     int
+	samples          []Sample
+}
+
+// parseLine consumes one line of output and returns the sample it produced,
+// if any. Debug lines only update the coverage state.
+func (p *progressParser) parseLine(line string) (Sample, bool) {
+	if m := initialCoverageLine.FindStringSubmatch(line); m != nil {
+		p.baselineCoverage, _ = strconv.Atoi(m[1])
+		p.coverageBits = p.baselineCoverage
+		return Sample{}, false
+	}
+	if m := totalCoverageLine.FindStringSubmatch(line); m != nil {
+		p.coverageBits, _ = strconv.Atoi(m[1])
+		return Sample{}, false
+	}
+
+	var s Sample
+	switch {
+	case execsLine.MatchString(line):
+		m := execsLine.FindStringSubmatch(line)
+		s.Elapsed, _ = time.ParseDuration(m[1])
+		s.Phase = PhaseFuzzing
+		s.Execs, _ = strconv.ParseInt(m[2], 10, 64)
+		s.ExecsPerSec, _ = strconv.ParseFloat(m[3], 64)
+		if m[4] != "" {
+			s.NewInteresting, _ = strconv.Atoi(m[4])
+			s.TotalInteresting, _ = strconv.Atoi(m[5])
+		}
+	case baselineLine.MatchString(line):
+		m := baselineLine.FindStringSubmatch(line)
+		s.Elapsed, _ = time.ParseDuration(m[1])
+		s.Phase = PhaseBaseline
+		s.BaselineDone, _ = strconv.Atoi(m[2])
+		s.BaselineTotal, _ = strconv.Atoi(m[3])
+	case minimizingLine.MatchString(line):
+		m := minimizingLine.FindStringSubmatch(line)
+		s.Elapsed, _ = time.ParseDuration(m[1])
+		s.Phase = PhaseMinimizing
+		if last, ok := p.last(); ok {
+			s.Execs = last.Execs
+			s.NewInteresting = last.NewInteresting
+			s.TotalInteresting = last.TotalInteresting
+		}
+	default:
+		return Sample{}, false
+	}
+
+	s.CoverageBits = p.coverageBits
+	p.samples = append(p.samples, s)
+
+	return s, true
+}
+
+// last returns the most recent sample
+func (p *progressParser) last() (Sample, bool) {
+	if len(p.samples) == 0 {
+		return Sample{}, false
+	}
+	return p.samples[len(p.samples)-1], true
+}
+
+// runStreaming runs cmd and hands every line of its combined output to
+// onLine as it is produced. Debug lines are left out of the returned output.
+func runStreaming(cmd *exec.Cmd, onLine func(line string)) (string, error) {
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	if err := cmd.Start(); err != nil {
+		pw.Close()
+		return "", err
+	}
+
+	var output strings.Builder
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			onLine(line)
+
+			if !strings.Contains(line, " DEBUG ") {
+				output.WriteString(line)
+				output.WriteByte('\n')
+			}
+		}
+
+		// Keep draining so the command never blocks on a full pipe
+		io.Copy(io.Discard, pr)
+	}()
+
+	err := cmd.Wait()
+	pw.Close()
+	<-done
+
+	return output.String(), err
+}
+
+// withFuzzDebug enables the fuzzer's debug output, which is the only place
+// go test reports coverage
+func withFuzzDebug(env []string) []string {
+	for i, kv := range env {
+		if value, ok := strings.CutPrefix(kv, "GODEBUG="); ok && value != "" {
+			env[i] = kv + ",fuzzdebug=1"
+			return env
+		}
+	}
+	return append(env, "GODEBUG=fuzzdebug=1")
+}
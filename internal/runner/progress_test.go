@@ -0,0 +1,128 @@
+// internal/runner/progress_test.go
+package runner
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		want     []Sample
+		coverage int
+	}{
+		{
+			name: "coverage guided run",
+			lines: []string{
+				"fuzz: elapsed: 0s, gathering baseline coverage: 0/4 completed",
+				"2026-10-16 09:01:09.15102479 DEBUG processed an initial input, id: , new bits: 1, size: 0, exec time: 118.928µs",
+				"fuzz: elapsed: 0s, gathering baseline coverage: 4/4 completed, now fuzzing with 2 workers",
+				"2026-10-16 09:01:09.152554043 DEBUG finished processing input corpus, entries: 4, initial coverage bits: 212",
+				"2026-10-16 09:01:10.481187339 DEBUG new interesting input, id: 5b0e7e3c4b3a3a18, parent: ad6b2f76c8e1c4c0, gen: 3, new bits: 4, total bits: 216, size: 7, exec time: 61.4µs",
+				"fuzz: elapsed: 3s, execs: 114598 (38171/sec), new interesting: 1 (total: 5)",
+				"2026-10-16 09:01:13.139488987 DEBUG stop called at /usr/local/go/src/internal/fuzz/fuzz.go:232. stopping: false",
+				"fuzz: elapsed: 1m3s, execs: 2159009 (43439/sec), new interesting: 1 (total: 5)",
+				"PASS",
+				"ok  \texample.com/fz\t63.028s",
+			},
+			want: []Sample{
+				{Phase: PhaseBaseline, BaselineTotal: 4},
+				{Phase: PhaseBaseline, BaselineDone: 4, BaselineTotal: 4},
+				{Elapsed: 3 * time.Second, Phase: PhaseFuzzing, Execs: 114598, ExecsPerSec: 38171, NewInteresting: 1, TotalInteresting: 5, CoverageBits: 216},
+				{Elapsed: time.Minute + 3*time.Second, Phase: PhaseFuzzing, Execs: 2159009, ExecsPerSec: 43439, NewInteresting: 1, TotalInteresting: 5, CoverageBits: 216},
+			},
+			coverage: 212,
+		},
+		{
+			name: "without coverage instrumentation",
+			lines: []string{
+				"warning: the test binary was not built with coverage instrumentation, so fuzzing will run without coverage guidance and may be inefficient",
+				"fuzz: elapsed: 0s, testing seed corpus: 2/2 completed, now fuzzing with 8 workers",
+				"fuzz: elapsed: 3s, execs: 98629 (32876/sec)",
+			},
+			want: []Sample{
+				{Phase: PhaseBaseline, BaselineDone: 2, BaselineTotal: 2},
+				{Elapsed: 3 * time.Second, Phase: PhaseFuzzing, Execs: 98629, ExecsPerSec: 32876},
+			},
+		},
+		{
+			name: "minimizing a failure",
+			lines: []string{
+				"fuzz: elapsed: 0s, gathering baseline coverage: 1/1 completed, now fuzzing with 2 workers",
+				"fuzz: elapsed: 3s, execs: 5301 (1767/sec), new interesting: 2 (total: 3)",
+				"fuzz: minimizing 48-byte failing input file",
+				"fuzz: elapsed: 6s, minimizing",
+				"--- FAIL: FuzzX (6.12s)",
+				"    --- FAIL: FuzzX (0.00s)",
+				"        testing.go:1591: panic: boom",
+				"    Failing input written to testdata/fuzz/FuzzX/582528ddfad69eb5",
+				"    To re-run:",
+				"    go test -run=FuzzX/582528ddfad69eb5",
+				"FAIL",
+			},
+			want: []Sample{
+				{Phase: PhaseBaseline, BaselineDone: 1, BaselineTotal: 1},
+				{Elapsed: 3 * time.Second, Phase: PhaseFuzzing, Execs: 5301, ExecsPerSec: 1767, NewInteresting: 2, TotalInteresting: 3},
+				{Elapsed: 6 * time.Second, Phase: PhaseMinimizing, Execs: 5301, NewInteresting: 2, TotalInteresting: 3},
+			},
+		},
+		{
+			name: "failing seed",
+			lines: []string{
+				"fuzz: elapsed: 0s, gathering baseline coverage: 0/3 completed",
+				"failure while testing seed corpus entry: FuzzX/seed#1",
+				"fuzz: elapsed: 0s, gathering baseline coverage: 1/3 completed",
+				"--- FAIL: FuzzX (0.03s)",
+			},
+			want: []Sample{
+				{Phase: PhaseBaseline, BaselineTotal: 3},
+				{Phase: PhaseBaseline, BaselineDone: 1, BaselineTotal: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &progressParser{}
+			var got []Sample
+			for _, line := range tt.lines {
+				if s, ok := p.parseLine(line); ok {
+					got = append(got, s)
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("samples =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			if !slices.Equal(p.samples, got) {
+				t.Errorf("kept samples %+v, want the returned ones", p.samples)
+			}
+			if p.baselineCoverage != tt.coverage {
+				t.Errorf("baseline coverage = %d, want %d", p.baselineCoverage, tt.coverage)
+			}
+		})
+	}
+}
+
+func TestWithFuzzDebug(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []string
+		want []string
+	}{
+		{"unset", []string{"HOME=/root"}, []string{"HOME=/root", "GODEBUG=fuzzdebug=1"}},
+		{"empty", []string{"GODEBUG="}, []string{"GODEBUG=", "GODEBUG=fuzzdebug=1"}},
+		{"other settings", []string{"GODEBUG=gctrace=1", "HOME=/root"}, []string{"GODEBUG=gctrace=1,fuzzdebug=1", "HOME=/root"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withFuzzDebug(tt.env); !slices.Equal(got, tt.want) {
+				t.Errorf("env = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
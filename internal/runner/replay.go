@@ -0,0 +1,197 @@
+// internal/runner/replay.go
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/crash"
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+)
+
+// Kinds of inputs replayed in regression mode
+const (
+	// InputCorpus is an entry of the managed corpus
+	InputCorpus = "corpus"
+
+	// InputCrasher is a failing input from the crash store
+	InputCrasher = "crasher"
+
+	// InputSeed is part of the package's own testdata seed corpus
+	InputSeed = "seed"
+)
+
+// failingSeedLine matches a failing seed in go test output,
+// e.g. "--- FAIL: FuzzX/5d383a64e88cd990 (0.00s)"
+var failingSeedLine = regexp.MustCompile(`--- FAIL: (Fuzz\w*)/(\S+) \(`)
+
+// FailingInput is a stored input that fails against the current code
+type FailingInput struct {
+	Name   string
+	Kind   string
+	Path   string
+	Output string
+}
+
+// ReplayResult is the result of replaying the stored inputs of a target
+type ReplayResult struct {
+	Target   *target.Target
+	Inputs   int
+	Failing  []*FailingInput
+	Duration time.Duration
+}
+
+// ReplayAll replays the managed corpus and the known crashers of every
+// target without fuzzing. Results are reported in target order.
+func (e *FuzzEngine) ReplayAll(ctx context.Context) ([]*ReplayResult, error) {
+	results := make([]*ReplayResult, len(e.Targets))
+	err := e.schedule(ctx, func(i, workers int) error {
+		result, err := e.ReplayTarget(ctx, e.Targets[i])
+		if err != nil {
+			return err
+		}
+
+		results[i] = result
+		return nil
+	})
+
+	var replayed []*ReplayResult
+	for _, result := range results {
+		if result != nil {
+			replayed = append(replayed, result)
+		}
+	}
+
+	return replayed, err
+}
+
+// ReplayTarget runs every stored input of a target once as part of its
+// seed corpus. A panicking input aborts the test binary, so failing inputs
+// are taken out one at a time and the target is rerun until it passes. The
+// stored inputs run apart from the package's own seeds, so a failing seed of
+// the package can't keep them from running.
+func (e *FuzzEngine) ReplayTarget(ctx context.Context, t *target.Target) (*ReplayResult, error) {
+	result := &ReplayResult{Target: t}
+	start := time.Now()
+
+	inputs := append(listInputs(e.CorpusManager.GetTargetDir(t)),
+		listInputs(e.CrashStore.TargetDir(t))...)
+	result.Inputs = len(inputs)
+
+	stage, err := stageSeeds(filepath.Dir(t.FilePath), t.Name, inputs)
+	if err != nil {
+		return nil, err
+	}
+	defer stage.Cleanup()
+
+	for len(stage.Seeds()) > 0 {
+		output, failing, err := e.replaySeeds(ctx, t, stage.Seeds())
+		if err != nil {
+			return nil, err
+		}
+		if len(failing) == 0 {
+			break
+		}
+
+		for _, seed := range failing {
+			src, ok := stage.Source(seed)
+			if !ok {
+				return nil, fmt.Errorf("go test reported unknown seed %s of %s:\n%s", seed, t.Name, output)
+			}
+
+			kind := InputCorpus
+			if strings.HasPrefix(src, e.CrashStore.TargetDir(t)) {
+				kind = InputCrasher
+			}
+			result.Failing = append(result.Failing, &FailingInput{
+				Name:   seed,
+				Kind:   kind,
+				Path:   src,
+				Output: crash.FailureText(output),
+			})
+			stage.Remove(seed)
+		}
+	}
+
+	// Failures in the package's own seeds can't be taken out, so the
+	// first one that panics hides any later ones
+	stage.Cleanup()
+	output, failing, err := e.replaySeeds(ctx, t, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, seed := range failing {
+		result.Failing = append(result.Failing, &FailingInput{
+			Name:   seed,
+			Kind:   InputSeed,
+			Path:   filepath.Join(filepath.Dir(t.FilePath), "testdata", "fuzz", t.Name, seed),
+			Output: crash.FailureText(output),
+		})
+	}
+
+	result.Duration = time.Since(start)
+
+	return result, nil
+}
+
+// replaySeeds runs the seed corpus of a target once, only the given seeds
+// if there are any, and returns the output and the distinct seeds that
+// failed
+func (e *FuzzEngine) replaySeeds(ctx context.Context, t *target.Target, seeds []string) (string, []string, error) {
+	run := fmt.Sprintf("^%s$", t.Name)
+	if len(seeds) > 0 {
+		quoted := make([]string, len(seeds))
+		for i, seed := range seeds {
+			quoted[i] = regexp.QuoteMeta(seed)
+		}
+		run += fmt.Sprintf("/^(%s)$", strings.Join(quoted, "|"))
+	}
+
+	cmd := buildCommand(ctx, e.Config, moduleDir(e.Config, t), "test",
+		"-run", run,
+		"-count", "1", // Never report cached results
+		t.Package)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return string(output), nil, nil
+	}
+	if ctx.Err() != nil {
+		return "", nil, ctx.Err()
+	}
+
+	var failing []string
+	for _, m := range failingSeedLine.FindAllStringSubmatch(string(output), -1) {
+		if m[1] == t.Name && !slices.Contains(failing, m[2]) {
+			failing = append(failing, m[2])
+		}
+	}
+	if len(failing) == 0 {
+		return "", nil, fmt.Errorf("go test failed: %w\n%s", err, output)
+	}
+
+	return string(output), failing, nil
+}
+
+// listInputs returns the stored inputs in dir, skipping crash details
+func listInputs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var inputs []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		inputs = append(inputs, filepath.Join(dir, entry.Name()))
+	}
+
+	return inputs
+}
@@ -0,0 +1,120 @@
+// internal/runner/report.go
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Report is the machine-readable summary of a fuzzing run
+type Report struct {
+	StartedAt time.Time       `json:"started_at"`
+	Duration  time.Duration   `json:"duration"`
+	Budget    time.Duration   `json:"budget,omitempty"`
+	Build     time.Duration   `json:"build_duration"`
+	Strategy  string          `json:"strategy"`
+	Targets   []*TargetReport `json:"targets"`
+	Skipped   []string        `json:"skipped,omitempty"`
+	Rounds    []*Round        `json:"rounds,omitempty"`
+}
+
+// TargetReport summarizes the run of a single target
+type TargetReport struct {
+	Package          string        `json:"package"`
+	Name             string        `json:"name"`
+	ChangeReason     string        `json:"change_reason,omitempty"`
+	Success          bool          `json:"success"`
+	Interrupted      bool          `json:"interrupted,omitempty"`
+	Duration         time.Duration `json:"duration"`
+	Allotted         time.Duration `json:"allotted,omitempty"`
+	BuildDuration    time.Duration `json:"build_duration"`
+	BinaryCached     bool          `json:"binary_cached"`
+	Rounds           int           `json:"rounds,omitempty"`
+	Error            string        `json:"error,omitempty"`
+	CrashInputs      []string      `json:"crash_inputs,omitempty"`
+	CrashHash        string        `json:"crash_hash,omitempty"`
+	CrashBucket      string        `json:"crash_bucket,omitempty"`
+	NewCorpusItems   int           `json:"new_corpus_items"`
+	Execs            int64         `json:"execs"`
+	ExecsPerSec      float64       `json:"execs_per_sec"`
+	NewInteresting   int           `json:"new_interesting"`
+	BaselineCoverage float64       `json:"baseline_coverage"`
+	Coverage         float64       `json:"coverage"`
+	Samples          []Sample      `json:"samples"`
+}
+
+// Report builds a report from the results collected so far
+func (e *FuzzEngine) Report() *Report {
+	report := &Report{
+		StartedAt: e.startedAt,
+		Duration:  time.Since(e.startedAt),
+		Budget:    e.Config.Budget,
+		Build:     e.BuildDuration,
+		Strategy:  e.Config.Strategy,
+		Rounds:    e.Rounds,
+	}
+
+	for _, r := range e.Results {
+		tr := &TargetReport{
+			Package:          r.Target.Package,
+			Name:             r.Target.Name,
+			ChangeReason:     r.Target.ChangeReason,
+			Success:          r.Success,
+			Interrupted:      r.Interrupted,
+			Duration:         r.Duration,
+			Allotted:         r.Allotted,
+			BuildDuration:    r.BuildDuration,
+			BinaryCached:     r.BinaryCached,
+			Rounds:           r.Rounds,
+			Error:            r.ErrorMessage,
+			CrashInputs:      r.CrashInputs,
+			NewCorpusItems:   r.NewCorpusItems,
+			Execs:            r.Execs,
+			ExecsPerSec:      r.ExecsPerSec,
+			NewInteresting:   r.NewInteresting,
+			BaselineCoverage: r.BaselineCoverage,
+			Coverage:         r.Coverage,
+			Samples:          r.Samples,
+		}
+		if r.Crash != nil {
+			tr.CrashHash = r.Crash.Hash
+			tr.CrashBucket = r.Crash.BucketID
+		}
+
+		report.Targets = append(report.Targets, tr)
+	}
+
+	for _, t := range e.Skipped {
+		report.Skipped = append(report.Skipped, t.Package+"."+t.Name)
+	}
+
+	return report
+}
+
+// runID identifies a run by when it started, as in the name of its report
+func runID(startedAt time.Time) string {
+	return startedAt.Format("20060102-150405")
+}
+
+// WriteReport writes the report as JSON into dir and returns its path
+func (e *FuzzEngine) WriteReport(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(e.Report(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	name := fmt.Sprintf("report-%s.json", runID(e.startedAt))
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,73 @@
+// internal/runner/reproduce.go
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/OmBiradar/go-fuzz-runner/internal/crash"
+	"github.com/OmBiradar/go-fuzz-runner/internal/target"
+	"github.com/OmBiradar/go-fuzz-runner/pkg/config"
+)
+
+// Reproduce replays a stored crash against the current code by running it
+// as a seed of its fuzz target. It reports whether the input still fails
+// along with the go test output.
+func Reproduce(ctx context.Context, cfg *config.Config, c *crash.Crash) (bool, string, error) {
+	dir, err := packageModuleDir(cfg, c.Package)
+	if err != nil {
+		return false, "", err
+	}
+
+	pkgDir, err := packageDir(ctx, cfg, dir, c.Package)
+	if err != nil {
+		return false, "", err
+	}
+
+	stage, err := stageSeeds(pkgDir, c.Name, []string{c.InputPath})
+	if err != nil {
+		return false, "", err
+	}
+	defer stage.Cleanup()
+
+	cmd := buildCommand(ctx, cfg, dir, "test",
+		"-run", fmt.Sprintf("^%s$/^%s$", c.Name, c.Hash),
+		c.Package)
+	output, err := cmd.CombinedOutput()
+
+	if strings.Contains(string(output), "--- FAIL") {
+		return true, string(output), nil
+	}
+	if err != nil {
+		return false, string(output), fmt.Errorf("go test failed: %w", err)
+	}
+
+	return false, string(output), nil
+}
+
+// packageModuleDir returns the directory of the module of the project a
+// package belongs to, or the root directory if no module matches
+func packageModuleDir(cfg *config.Config, pkg string) (string, error) {
+	modules, err := target.FindModules(cfg.RootDir, cfg.AllModules)
+	if err != nil {
+		return "", fmt.Errorf("failed to find modules: %w", err)
+	}
+
+	if m := target.ModuleOf(modules, pkg); m != nil {
+		return m.Dir, nil
+	}
+	return cfg.RootDir, nil
+}
+
+// packageDir returns the source directory of a package, listed from the
+// directory of its module
+func packageDir(ctx context.Context, cfg *config.Config, dir, pkg string) (string, error) {
+	cmd := buildCommand(ctx, cfg, dir, "list", "-f", "{{.Dir}}", pkg)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list failed: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
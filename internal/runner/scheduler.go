@@ -0,0 +1,107 @@
+// internal/runner/scheduler.go
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// lane is a scheduling slot that runs one target at a time with a fixed
+// share of the global worker budget
+type lane struct {
+	id      int
+	workers int
+}
+
+// planLanes splits the parallelism budget across concurrently running targets.
+// When concurrent is zero, every target gets a single worker and as many
+// targets as the budget allows run side by side.
+func planLanes(parallelism, concurrent, targets int) []lane {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if concurrent <= 0 || concurrent > parallelism {
+		concurrent = parallelism
+	}
+	if targets > 0 && concurrent > targets {
+		concurrent = targets
+	}
+	if concurrent < 1 {
+		concurrent = 1
+	}
+
+	// Hand out the remainder one worker at a time so the whole budget is used
+	lanes := make([]lane, concurrent)
+	for i := range lanes {
+		lanes[i] = lane{id: i, workers: parallelism / concurrent}
+		if i < parallelism%concurrent {
+			lanes[i].workers++
+		}
+	}
+
+	return lanes
+}
+
+// schedule calls fn for every target on the lanes planned from the
+// parallelism budget, passing the target's index and its number of fuzz
+// workers. It stops handing out targets after the first error or once ctx
+// is cancelled, and waits for the running ones to finish.
+func (e *FuzzEngine) schedule(ctx context.Context, fn func(i, workers int) error) error {
+	lanes := planLanes(e.Config.Parallelism, e.Config.ConcurrentTargets, len(e.Targets))
+	jobs := make(chan int)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for _, l := range lanes {
+		wg.Add(1)
+		go func(l lane) {
+			defer wg.Done()
+
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				if err := fn(i, l.workers); err != nil {
+					t := e.Targets[i]
+
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to run target %s.%s: %w",
+							t.Package, t.Name, err)
+					}
+					mu.Unlock()
+				}
+			}
+		}(l)
+	}
+
+dispatch:
+	for i := range e.Targets {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+
+	return firstErr
+}
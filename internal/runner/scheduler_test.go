@@ -0,0 +1,42 @@
+// internal/runner/scheduler_test.go
+package runner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlanLanes(t *testing.T) {
+	tests := []struct {
+		name                             string
+		parallelism, concurrent, targets int
+		workers                          []int
+	}{
+		{"one worker per target by default", 4, 0, 10, []int{1, 1, 1, 1}},
+		{"fewer targets than workers", 4, 0, 2, []int{2, 2}},
+		{"remainder goes to the first lanes", 7, 3, 10, []int{3, 2, 2}},
+		{"concurrency capped by parallelism", 2, 5, 10, []int{1, 1}},
+		{"concurrency capped by targets", 8, 4, 3, []int{3, 3, 2}},
+		{"single target gets the whole budget", 8, 0, 1, []int{8}},
+		{"no targets", 4, 2, 0, []int{2, 2}},
+		{"invalid parallelism", 0, 0, 3, []int{1}},
+		{"negative concurrency", 3, -1, 5, []int{1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lanes := planLanes(tt.parallelism, tt.concurrent, tt.targets)
+
+			var workers []int
+			for i, l := range lanes {
+				if l.id != i {
+					t.Errorf("lane %d has id %d", i, l.id)
+				}
+				workers = append(workers, l.workers)
+			}
+			if !slices.Equal(workers, tt.workers) {
+				t.Errorf("workers = %v, want %v", workers, tt.workers)
+			}
+		})
+	}
+}
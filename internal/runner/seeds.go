@@ -0,0 +1,132 @@
+// internal/runner/seeds.go
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// seedStage tracks inputs temporarily added to a target's seed corpus
+type seedStage struct {
+	dir     string
+	parents []string
+	files   []string
+	staged  map[string]string
+	done    bool
+}
+
+// stagedDirs counts the stages using each seed corpus directory, so targets
+// of the same package staged at the same time share testdata and
+// testdata/fuzz. Directories a stage created are removed once the last
+// stage using them is cleaned up.
+var stagedDirs = struct {
+	sync.Mutex
+	users   map[string]int
+	created map[string]bool
+}{
+	users:   make(map[string]int),
+	created: make(map[string]bool),
+}
+
+// stageSeeds copies inputs into the seed corpus of a target, i.e.
+// testdata/fuzz/<FuzzName> in the package directory, where go test runs
+// them as regular tests. Inputs already present are left untouched.
+// Cleanup removes everything that was added.
+func stageSeeds(pkgDir, name string, inputs []string) (*seedStage, error) {
+	s := &seedStage{staged: make(map[string]string)}
+
+	// Create missing directories one level at a time so they can be
+	// removed again afterwards
+	stagedDirs.Lock()
+	s.dir = pkgDir
+	for _, elem := range []string{"testdata", "fuzz", name} {
+		s.dir = filepath.Join(s.dir, elem)
+		if err := os.Mkdir(s.dir, 0755); err == nil {
+			stagedDirs.created[s.dir] = true
+		} else if !errors.Is(err, os.ErrExist) {
+			stagedDirs.Unlock()
+			s.Cleanup()
+			return nil, fmt.Errorf("failed to create seed corpus directory: %w", err)
+		}
+		stagedDirs.users[s.dir]++
+		s.parents = append(s.parents, s.dir)
+	}
+	stagedDirs.Unlock()
+
+	for _, input := range inputs {
+		seed := filepath.Base(input)
+		dst := filepath.Join(s.dir, seed)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+
+		if err := copyFile(input, dst); err != nil {
+			s.Cleanup()
+			return nil, fmt.Errorf("failed to stage seed %s: %w", seed, err)
+		}
+		s.files = append(s.files, dst)
+		s.staged[seed] = input
+	}
+
+	return s, nil
+}
+
+// Source returns the original path of a staged seed, or false if the seed
+// was already part of the package's own seed corpus
+func (s *seedStage) Source(seed string) (string, bool) {
+	src, ok := s.staged[seed]
+	return src, ok
+}
+
+// Seeds returns the names of the seeds that are still staged, sorted
+func (s *seedStage) Seeds() []string {
+	seeds := make([]string, 0, len(s.staged))
+	for seed := range s.staged {
+		seeds = append(seeds, seed)
+	}
+	sort.Strings(seeds)
+	return seeds
+}
+
+// Remove takes a staged seed out of the seed corpus again
+func (s *seedStage) Remove(seed string) {
+	if _, ok := s.staged[seed]; ok {
+		os.Remove(filepath.Join(s.dir, seed))
+		delete(s.staged, seed)
+	}
+}
+
+// Cleanup removes all staged seeds, and the directories created for them
+// once no other stage uses them. It may be called more than once.
+func (s *seedStage) Cleanup() {
+	if s.done {
+		return
+	}
+	s.done = true
+
+	for _, file := range s.files {
+		os.Remove(file)
+	}
+
+	stagedDirs.Lock()
+	defer stagedDirs.Unlock()
+	for i := len(s.parents) - 1; i >= 0; i-- {
+		dir := s.parents[i]
+		stagedDirs.users[dir]--
+		if stagedDirs.users[dir] > 0 {
+			continue
+		}
+		delete(stagedDirs.users, dir)
+
+		// Only empty directories go. One that got other files is kept
+		// from then on, like a directory that was there before.
+		if stagedDirs.created[dir] {
+			os.Remove(dir)
+			delete(stagedDirs.created, dir)
+		}
+	}
+}
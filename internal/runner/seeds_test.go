@@ -0,0 +1,99 @@
+// internal/runner/seeds_test.go
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// exists reports whether a path exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestStageSeeds(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(input, []byte("go test fuzz v1\nint(1)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		existing string
+	}{
+		{"no testdata", ""},
+		{"own testdata", "testdata"},
+		{"own seed corpus", "testdata/fuzz/FuzzA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgDir := t.TempDir()
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Join(pkgDir, tt.existing), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			a, err := stageSeeds(pkgDir, "FuzzA", []string{input})
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := stageSeeds(pkgDir, "FuzzB", []string{input})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !exists(filepath.Join(pkgDir, "testdata", "fuzz", "FuzzA", "input")) {
+				t.Fatal("input wasn't staged")
+			}
+
+			// The directories stay while the other target uses them
+			a.Cleanup()
+			a.Cleanup()
+			if !exists(filepath.Join(pkgDir, "testdata", "fuzz", "FuzzB", "input")) {
+				t.Error("cleaning up one stage removed the seeds of the other")
+			}
+
+			b.Cleanup()
+			if tt.existing == "" {
+				if exists(filepath.Join(pkgDir, "testdata")) {
+					t.Error("testdata was left behind")
+				}
+				return
+			}
+			if !exists(filepath.Join(pkgDir, tt.existing)) {
+				t.Errorf("%s was removed", tt.existing)
+			}
+			if exists(filepath.Join(pkgDir, "testdata", "fuzz", "FuzzB")) {
+				t.Error("staged seed corpus was left behind")
+			}
+		})
+	}
+}
+
+func TestStageSeedsConcurrently(t *testing.T) {
+	pkgDir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := stageSeeds(pkgDir, fmt.Sprintf("Fuzz%d", i), nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.Cleanup()
+		}()
+	}
+	wg.Wait()
+
+	if exists(filepath.Join(pkgDir, "testdata")) {
+		t.Error("testdata was left behind")
+	}
+}
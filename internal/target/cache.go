@@ -0,0 +1,320 @@
+// internal/target/cache.go
+package target
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// cacheVersion changes whenever the cached data changes, so caches written
+// by other versions are ignored
+const cacheVersion = 2
+
+// listedFiles is the part of the output of go list -json that decides
+// whether the targets of a package may have changed
+type listedFiles struct {
+	ImportPath   string
+	Dir          string
+	Standard     bool
+	DepOnly      bool
+	ForTest      string
+	Imports      []string
+	GoFiles      []string
+	CgoFiles     []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	Module       *struct {
+		Path    string
+		Version string
+		Main    bool
+		Replace *struct {
+			Version string
+		}
+	}
+	Error *struct {
+		Err string
+	}
+}
+
+// discoveryCache holds the targets discovered in the packages of a module
+type discoveryCache struct {
+	Version  int                       `json:"version"`
+	Packages map[string]*cachedPackage `json:"packages"`
+}
+
+// cachedPackage holds the targets of a package together with the key of
+// the files they were discovered in
+type cachedPackage struct {
+	Key     string    `json:"key"`
+	Targets []*Target `json:"targets"`
+}
+
+// cachedTargets finds the fuzz targets of the packages matching the
+// patterns like findTargets, but only type-checks the packages whose files
+// or dependencies changed since they were cached. Listing the packages and
+// hashing their files is much cheaper than loading them.
+func cachedTargets(options DiscoveryOptions) ([]*Target, error) {
+	listed, err := listFiles(options)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := cachePath(options)
+	if err != nil {
+		return nil, err
+	}
+	cache := readCache(path)
+
+	graph := make(map[string]*listedFiles)
+	for _, p := range listed {
+		graph[p.ImportPath] = p
+	}
+
+	var (
+		targets []*Target
+		misses  []string
+		keys    = make(map[string]string)
+		hashes  = make(map[string]string)
+	)
+	for _, p := range listed {
+		// Dependencies and test variants are only part of the keys
+		if p.DepOnly || p.ForTest != "" || strings.HasSuffix(p.ImportPath, ".test") {
+			continue
+		}
+
+		// Loading reports the errors of broken packages
+		if p.Error != nil {
+			misses = append(misses, p.ImportPath)
+			continue
+		}
+		if len(p.TestGoFiles) == 0 && len(p.XTestGoFiles) == 0 {
+			continue
+		}
+
+		key, err := packageKey(options, graph, hashes, p)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := cache.Packages[p.ImportPath]; ok && c.Key == key {
+			targets = append(targets, c.Targets...)
+			continue
+		}
+
+		keys[p.ImportPath] = key
+		misses = append(misses, p.ImportPath)
+	}
+
+	if len(misses) == 0 {
+		return targets, nil
+	}
+
+	found, err := findTargets(options, misses)
+	if err != nil {
+		return nil, err
+	}
+	targets = append(targets, found...)
+
+	for pkg, key := range keys {
+		c := &cachedPackage{Key: key, Targets: []*Target{}}
+		for _, t := range found {
+			if t.Package == pkg {
+				c.Targets = append(c.Targets, t)
+			}
+		}
+		cache.Packages[pkg] = c
+	}
+
+	// Failing to cache only costs time on the next run
+	writeCache(path, cache)
+
+	return targets, nil
+}
+
+// listFiles lists the packages matching the patterns together with their
+// files, as selected by the build tags, followed by the packages their test
+// binaries depend on
+func listFiles(options DiscoveryOptions) ([]*listedFiles, error) {
+	args := []string{"list", "-e", "-deps", "-test",
+		"-json=ImportPath,Dir,Standard,DepOnly,ForTest,Imports,GoFiles,CgoFiles,TestGoFiles,XTestGoFiles,Module,Error"}
+	args = append(args, buildFlags(options.BuildTags)...)
+	cmd := exec.Command("go", append(args, options.Patterns...)...)
+	cmd.Dir = options.RootDir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var listed []*listedFiles
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for {
+		p := &listedFiles{}
+		if err := dec.Decode(p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		listed = append(listed, p)
+	}
+
+	return listed, nil
+}
+
+// packageKey hashes what the targets of a package are discovered from: the
+// names and contents of its files, and the sources of every package its
+// test binary depends on, since seeds may be built from their constants.
+// hashes memoizes the sources of dependencies by directory.
+func packageKey(options DiscoveryOptions, graph map[string]*listedFiles, hashes map[string]string, p *listedFiles) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00", cacheVersion, p.ImportPath, p.Dir, strings.Join(options.BuildTags, ","))
+
+	files := slices.Concat(p.GoFiles, p.CgoFiles, p.TestGoFiles, p.XTestGoFiles)
+	if err := hashFiles(h, p.Dir, files); err != nil {
+		return "", err
+	}
+
+	// Collect the dependencies of the test binary, or of the package if it
+	// has none
+	root := p.ImportPath + ".test"
+	if _, ok := graph[root]; !ok {
+		root = p.ImportPath
+	}
+	deps := make(map[string]*listedFiles)
+	queue := []string{root}
+	for len(queue) > 0 {
+		dep, ok := graph[queue[0]]
+		queue = queue[1:]
+		if !ok || dep.Standard {
+			continue
+		}
+
+		for _, imp := range dep.Imports {
+			if _, seen := deps[imp]; !seen {
+				deps[imp] = nil
+				queue = append(queue, imp)
+			}
+		}
+
+		// The package's own files, including its test variants, are
+		// hashed above, and the test main package is generated
+		if dep.Dir != p.Dir && !strings.HasSuffix(dep.ImportPath, ".test") {
+			deps[dep.ImportPath] = dep
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(deps)) {
+		dep := deps[name]
+		if dep == nil {
+			continue
+		}
+
+		hash, ok := hashes[dep.Dir]
+		if !ok {
+			var err error
+			if hash, err = sourceHash(dep); err != nil {
+				return "", err
+			}
+			hashes[dep.Dir] = hash
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", name, hash)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// sourceHash hashes the files of a dependency, or only its version if it
+// belongs to a module at a fixed version
+func sourceHash(p *listedFiles) (string, error) {
+	if m := p.Module; m != nil && !m.Main && (m.Replace == nil || m.Replace.Version != "") {
+		return m.Path + "@" + m.Version, nil
+	}
+
+	h := sha256.New()
+	if err := hashFiles(h, p.Dir, slices.Concat(p.GoFiles, p.CgoFiles)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// hashFiles writes the names and contents of files in dir to h
+func hashFiles(h io.Writer, dir string, files []string) error {
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(data))
+		h.Write(data)
+	}
+	return nil
+}
+
+// cachePath returns the cache file of the root directory, i.e. the module
+func cachePath(options DiscoveryOptions) (string, error) {
+	root, err := filepath.Abs(options.RootDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%x", sha256.Sum256([]byte(root)))[:16] + ".json"
+	return filepath.Join(options.CacheDir, name), nil
+}
+
+// readCache reads a cache file. A missing, unreadable or outdated cache is
+// an empty one.
+func readCache(path string) *discoveryCache {
+	empty := &discoveryCache{Version: cacheVersion, Packages: make(map[string]*cachedPackage)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return empty
+	}
+
+	cache := &discoveryCache{}
+	if err := json.Unmarshal(data, cache); err != nil || cache.Version != cacheVersion || cache.Packages == nil {
+		return empty
+	}
+
+	return cache
+}
+
+// writeCache replaces a cache file. The file is renamed into place so
+// concurrent commands never read a partial cache.
+func writeCache(path string, cache *discoveryCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".discovery-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
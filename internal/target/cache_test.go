@@ -0,0 +1,138 @@
+// internal/target/cache_test.go
+package target
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// cacheModule has two packages with a target each, and FuzzA's seed is a
+// constant of the package c through the package d
+var cacheModule = map[string]string{
+	"a/a.go":      "package a\n",
+	"a/a_test.go": "package a\n\nimport (\n\t\"testing\"\n\n\t\"example.com/m/d\"\n)\n\nfunc FuzzA(f *testing.F) { f.Add(d.Seed) }\n",
+	"b/b.go":      "package b\n",
+	"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc FuzzB(f *testing.F) {}\n",
+	"c/c.go":      "package c\n\nconst Seed = \"x\"\n",
+	"c/c_test.go": "package c\n",
+	"d/d.go":      "package d\n\nimport \"example.com/m/c\"\n\nconst Seed = c.Seed\n",
+}
+
+func TestCachedTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		tags    []string
+
+		// Targets found, and whether they came from the cache
+		want map[string]bool
+
+		// Seed of FuzzA
+		seed string
+	}{
+		{
+			name: "nothing changed",
+			want: map[string]bool{"FuzzA": true, "FuzzB": true},
+			seed: `string("x")`,
+		},
+		{
+			name:    "test file changed",
+			changes: map[string]string{"a/a_test.go": cacheModule["a/a_test.go"] + "\nfunc FuzzNew(f *testing.F) {}\n"},
+			want:    map[string]bool{"FuzzA": false, "FuzzNew": false, "FuzzB": true},
+			seed:    `string("x")`,
+		},
+		{
+			name:    "package file changed",
+			changes: map[string]string{"b/b.go": "package b\n\nconst Seed = 1\n"},
+			want:    map[string]bool{"FuzzA": true, "FuzzB": false},
+			seed:    `string("x")`,
+		},
+		{
+			name:    "constant of a dependency changed",
+			changes: map[string]string{"c/c.go": "package c\n\nconst Seed = \"y\"\n"},
+			want:    map[string]bool{"FuzzA": false, "FuzzB": true},
+			seed:    `string("y")`,
+		},
+		{
+			name:    "test file of a dependency changed",
+			changes: map[string]string{"c/c_test.go": "package c\n\nconst Other = 1\n"},
+			want:    map[string]bool{"FuzzA": true, "FuzzB": true},
+			seed:    `string("x")`,
+		},
+		{
+			name:    "file added",
+			changes: map[string]string{"b/more_test.go": "package b\n\nimport \"testing\"\n\nfunc FuzzMore(f *testing.F) {}\n"},
+			want:    map[string]bool{"FuzzA": true, "FuzzB": false, "FuzzMore": false},
+			seed:    `string("x")`,
+		},
+		{
+			name:    "package added",
+			changes: map[string]string{"c/c_test.go": "package c\n\nimport \"testing\"\n\nfunc FuzzC(f *testing.F) {}\n"},
+			want:    map[string]bool{"FuzzA": true, "FuzzB": true, "FuzzC": false},
+			seed:    `string("x")`,
+		},
+		{
+			name: "other build tags",
+			tags: []string{"extra"},
+			want: map[string]bool{"FuzzA": false, "FuzzB": false},
+			seed: `string("x")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newModule(t, cacheModule)
+			options := DiscoveryOptions{RootDir: dir, Patterns: []string{"./..."}, CacheDir: t.TempDir()}
+
+			if _, err := DiscoverTargets(options); err != nil {
+				t.Fatal(err)
+			}
+
+			// Mark the cached targets to tell them from discovered ones
+			path, err := cachePath(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cache := readCache(path)
+			if len(cache.Packages) != 3 {
+				t.Fatalf("cached %d packages, want 3", len(cache.Packages))
+			}
+			for _, c := range cache.Packages {
+				for _, tg := range c.Targets {
+					tg.Description = "cached"
+				}
+			}
+			if err := writeCache(path, cache); err != nil {
+				t.Fatal(err)
+			}
+
+			writeFiles(t, dir, tt.changes)
+			options.BuildTags = tt.tags
+			targets, err := DiscoverTargets(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]bool)
+			for _, tg := range targets {
+				got[tg.Name] = tg.Description == "cached"
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+			if seeds := findTarget(t, targets, "FuzzA").Seeds; len(seeds) != 1 || !slices.Equal(seeds[0].Values, []string{tt.seed}) {
+				t.Errorf("FuzzA seeds = %v, want %s", seeds, tt.seed)
+			}
+
+			// Discovered targets are cached for the next run
+			for _, c := range readCache(path).Packages {
+				for _, tg := range c.Targets {
+					if !tt.want[tg.Name] && tg.Description == "cached" {
+						t.Errorf("%s wasn't cached again", tg.Name)
+					}
+				}
+			}
+		})
+	}
+}
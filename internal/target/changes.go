@@ -0,0 +1,255 @@
+// internal/target/changes.go
+package target
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// listedPackage is the part of the output of go list -json used to build
+// the import graph
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	Imports    []string
+	Module     *struct {
+		Path string
+		Dir  string
+		Main bool
+	}
+}
+
+// changeSet maps the files changed since a git reference to the packages
+// of the main modules
+type changeSet struct {
+	// Changed files, relative to the repository root, per package directory
+	dirs map[string][]string
+
+	// Changed go.mod and go.sum files per module directory
+	modules map[string][]string
+}
+
+// selectChanged returns the targets whose package, or any package of the
+// main modules it transitively depends on, changed since the git reference. Each
+// selected target gets a ChangeReason.
+func selectChanged(options DiscoveryOptions, targets []*Target) ([]*Target, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	changes, err := changedFiles(options.RootDir, options.GitRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []string
+	for _, t := range targets {
+		if !slices.Contains(pkgs, t.Package) {
+			pkgs = append(pkgs, t.Package)
+		}
+	}
+
+	graph, err := listDeps(options, pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Explain each package once, all targets in it share the reason
+	reasons := make(map[string]string)
+	for _, pkg := range pkgs {
+		if reason := changes.explain(graph, pkg); reason != "" {
+			reasons[pkg] = reason
+		}
+	}
+
+	var selected []*Target
+	for _, t := range targets {
+		if reason, ok := reasons[t.Package]; ok {
+			t.ChangeReason = reason
+			selected = append(selected, t)
+		}
+	}
+
+	return selected, nil
+}
+
+// explain returns why the test binary of pkg is affected by the changes,
+// or an empty string if it isn't
+func (c *changeSet) explain(graph map[string]*listedPackage, pkg string) string {
+	root := pkg + ".test"
+
+	// Walk the imports breadth first, so the shortest chain is reported.
+	// path holds the packages between pkg and the current one.
+	type step struct {
+		name string
+		path []string
+	}
+	queue := []step{{name: root}}
+	seen := map[string]bool{root: true}
+
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+
+		p, ok := graph[s.name]
+		if !ok {
+			continue
+		}
+
+		next := s.path
+		if s.name != root {
+			// Only packages of the main modules can change in the diff
+			if p.Module == nil || !p.Module.Main {
+				continue
+			}
+			if reason := c.reason(p, pkg, s.path); reason != "" {
+				return reason
+			}
+			if path := packagePath(p); path != pkg {
+				next = append(slices.Clip(s.path), path)
+			}
+		}
+
+		for _, imp := range p.Imports {
+			if !seen[imp] {
+				seen[imp] = true
+				queue = append(queue, step{name: imp, path: next})
+			}
+		}
+	}
+
+	return ""
+}
+
+// reason describes the changes to a package p in the dependencies of the
+// target package pkg, reached through path
+func (c *changeSet) reason(p *listedPackage, pkg string, path []string) string {
+	if files := c.dirs[p.Dir]; len(files) > 0 {
+		if packagePath(p) == pkg {
+			return fmt.Sprintf("package changed: %s", strings.Join(files, ", "))
+		}
+		return fmt.Sprintf("depends on changed package %s (%s)%s",
+			packagePath(p), strings.Join(files, ", "), via(path))
+	}
+
+	if files := c.modules[p.Module.Dir]; len(files) > 0 {
+		return fmt.Sprintf("module %s changed: %s", p.Module.Path, strings.Join(files, ", "))
+	}
+
+	return ""
+}
+
+// via formats the packages in between a target package and a changed one
+func via(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return " via " + strings.Join(path, " -> ")
+}
+
+// packagePath strips the test variant suffix from an import path, e.g.
+// "github.com/x/y [github.com/x/y.test]"
+func packagePath(p *listedPackage) string {
+	path, _, _ := strings.Cut(p.ImportPath, " ")
+	return path
+}
+
+// changedFiles lists the files that differ from gitRef, including files that
+// aren't tracked yet, and groups them by directory
+func changedFiles(rootDir, gitRef string) (*changeSet, error) {
+	top, err := gitOutput(rootDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top = strings.TrimSpace(top)
+
+	diff, err := gitOutput(rootDir, "diff", "--name-only", gitRef, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(rootDir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &changeSet{
+		dirs:    make(map[string][]string),
+		modules: make(map[string][]string),
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(diff + untracked))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			continue
+		}
+
+		dir := filepath.Join(top, filepath.Dir(filepath.FromSlash(name)))
+		switch filepath.Base(name) {
+		case "go.mod", "go.sum":
+			c.modules[dir] = append(c.modules[dir], name)
+		default:
+			c.dirs[dir] = append(c.dirs[dir], name)
+		}
+	}
+
+	for _, files := range c.dirs {
+		sort.Strings(files)
+	}
+
+	return c, nil
+}
+
+// listDeps loads the packages the test binaries of pkgs are built from,
+// keyed by import path, including test variants such as "p [p.test]"
+func listDeps(options DiscoveryOptions, pkgs []string) (map[string]*listedPackage, error) {
+	args := []string{"list", "-deps", "-test", "-e", "-json=ImportPath,Dir,Imports,Module"}
+	args = append(args, buildFlags(options.BuildTags)...)
+	cmd := exec.Command("go", append(args, pkgs...)...)
+	cmd.Dir = options.RootDir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	graph := make(map[string]*listedPackage)
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for {
+		p := &listedPackage{}
+		if err := dec.Decode(p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		graph[p.ImportPath] = p
+	}
+
+	return graph, nil
+}
+
+// gitOutput runs git in dir and returns its output
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(output), nil
+}
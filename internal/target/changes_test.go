@@ -0,0 +1,126 @@
+// internal/target/changes_test.go
+package target
+
+import (
+	"maps"
+	"os/exec"
+	"testing"
+)
+
+// git runs a git command in dir
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, output)
+	}
+}
+
+// newRepo writes a module into a new git repository and commits it
+func newRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := newModule(t, files)
+	git(t, dir, "init", "-q")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+
+	return dir
+}
+
+// changesModule has the packages d -> a -> b, and c, each with a fuzz test
+var changesModule = map[string]string{
+	"a/a.go":      "package a\n\nimport \"example.com/m/b\"\n\nfunc A() int { return b.B() }\n",
+	"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc FuzzA(f *testing.F) { A() }\n",
+	"b/b.go":      "package b\n\nfunc B() int { return 1 }\n",
+	"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc FuzzB(f *testing.F) {}\n",
+	"c/c.go":      "package c\n",
+	"c/c_test.go": "package c\n\nimport \"testing\"\n\nfunc FuzzC(f *testing.F) {}\n",
+	"d/d_test.go": "package d\n\nimport (\n\t\"testing\"\n\n\t\"example.com/m/a\"\n)\n\nfunc FuzzD(f *testing.F) { a.A() }\n",
+	"README.md":   "m\n",
+}
+
+func TestSelectChanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "nothing changed",
+			changes: nil,
+			want:    map[string]string{},
+		},
+		{
+			name:    "file outside of packages",
+			changes: map[string]string{"README.md": "changed\n"},
+			want:    map[string]string{},
+		},
+		{
+			name:    "package and its dependents",
+			changes: map[string]string{"b/b.go": "package b\n\nfunc B() int { return 2 }\n"},
+			want: map[string]string{
+				"FuzzA": "depends on changed package example.com/m/b (b/b.go)",
+				"FuzzB": "package changed: b/b.go",
+				"FuzzD": "depends on changed package example.com/m/b (b/b.go) via example.com/m/a",
+			},
+		},
+		{
+			name:    "test file",
+			changes: map[string]string{"c/c_test.go": "package c\n\nimport \"testing\"\n\nfunc FuzzC(f *testing.F) { f.Add(1) }\n"},
+			want:    map[string]string{"FuzzC": "package changed: c/c_test.go"},
+		},
+		{
+			name:    "untracked file",
+			changes: map[string]string{"c/new.go": "package c\n"},
+			want:    map[string]string{"FuzzC": "package changed: c/new.go"},
+		},
+		{
+			name:    "closest change",
+			changes: map[string]string{"a/a.go": "package a\n\nimport \"example.com/m/b\"\n\nfunc A() int { return b.B() + 1 }\n", "b/b.go": "package b\n\nfunc B() int { return 2 }\n"},
+			want: map[string]string{
+				"FuzzA": "package changed: a/a.go",
+				"FuzzB": "package changed: b/b.go",
+				"FuzzD": "depends on changed package example.com/m/a (a/a.go)",
+			},
+		},
+		{
+			name:    "go.mod",
+			changes: map[string]string{"go.mod": "module example.com/m\n\ngo 1.23\n"},
+			want: map[string]string{
+				"FuzzA": "module example.com/m changed: go.mod",
+				"FuzzB": "module example.com/m changed: go.mod",
+				"FuzzC": "module example.com/m changed: go.mod",
+				"FuzzD": "module example.com/m changed: go.mod",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newRepo(t, changesModule)
+			writeFiles(t, dir, tt.changes)
+
+			targets, err := DiscoverTargets(DiscoveryOptions{
+				RootDir:     dir,
+				Patterns:    []string{"./..."},
+				ChangedOnly: true,
+				GitRef:      "HEAD",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			for _, tg := range targets {
+				got[tg.Name] = tg.ChangeReason
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,11 +4,13 @@ package target
 import (
 	"fmt"
 	"go/ast"
-	"go/parser"
-	"go/token"
-	"os/exec"
-	"path/filepath"
+	"go/types"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
 )
 
 // Target represents a fuzz test target
@@ -18,169 +20,333 @@ type Target struct {
 	FilePath    string
 	FuncName    string
 	Description string
+
+	// Module is the path of the module the target's package belongs to and
+	// ModuleDir its root directory, which go commands for the target run in
+	Module    string
+	ModuleDir string
+
+	// ChangeReason explains why the target was selected by change detection
+	ChangeReason string
+
+	// Impact is set when targets were selected by function-level impact
+	Impact *Impact
+
+	// Params are the types of the fuzz arguments passed to the f.Fuzz
+	// callback after *testing.T, e.g. "[]byte" or "int"
+	Params []string
+
+	// Seeds are the inputs added with f.Add
+	Seeds []Seed
+
+	// Annotations are the //fuzz: directives of the doc comment, which
+	// Description leaves out
+	Annotations Annotations
+}
+
+// Seed is an input added to the seed corpus with f.Add
+type Seed struct {
+	// Values of the arguments in the corpus file format, e.g. `int(5)` or
+	// `[]byte("abc")`, or the source expressions if they aren't constant
+	Values []string
+
+	// Static is set when all values were known without running the code
+	Static bool
+
+	// Line of the f.Add call
+	Line int
+}
+
+// Signature returns the type of the fuzz arguments, e.g. "func([]byte, int)".
+// Targets with the same signature can read each other's corpus.
+func (t *Target) Signature() string {
+	return "func(" + strings.Join(t.Params, ", ") + ")"
 }
 
 // DiscoveryOptions configures the discovery process
 type DiscoveryOptions struct {
+	// RootDir is the directory patterns are relative to. Targets are
+	// discovered in every module of its go.work workspace, or in the module
+	// it belongs to if there is none.
 	RootDir     string
 	Patterns    []string
 	ChangedOnly bool
 	GitRef      string
+
+	// BuildTags are the build tags test files are selected with. GOOS,
+	// GOARCH and other build settings come from the environment.
+	BuildTags []string
+
+	// FunctionImpact narrows changed targets down to the ones whose call
+	// graph reaches changed functions, ranked by how much changed code
+	// they reach
+	FunctionImpact bool
+
+	// AllModules discovers targets in every module below RootDir when it
+	// isn't part of a workspace, not only in the one it belongs to
+	AllModules bool
+
+	// CacheDir, if set, is where the targets of each package are cached
+	// until its files change
+	CacheDir string
 }
 
-// DiscoverTargets finds all fuzz targets within the given options
-func DiscoverTargets(options DiscoveryOptions) ([]*Target, error) {
-	var targets []*Target
+// loadMode is what discovery needs to know about a package to find its
+// fuzz functions by type
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedForTest |
+	packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedModule
 
-	// Get list of packages matching patterns
-	pkgs, err := listPackages(options.RootDir, options.Patterns)
+// DiscoverTargets finds all fuzz targets within the given options. Packages
+// are loaded module by module, from each module's directory, so targets of
+// modules that aren't part of a workspace are found too.
+func DiscoverTargets(options DiscoveryOptions) ([]*Target, error) {
+	modules, err := FindModules(options.RootDir, options.AllModules)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list packages: %w", err)
+		return nil, fmt.Errorf("failed to find modules: %w", err)
 	}
 
-	// For each package, find fuzz targets
-	for _, pkg := range pkgs {
-		pkgTargets, err := findTargetsInPackage(pkg)
+	var targets []*Target
+	if len(modules) == 0 {
+		// Outside of module mode, e.g. in GOPATH mode
+		if targets, err = discoverModule(options); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range modules {
+		patterns, err := modulePatterns(m, modules, options.RootDir, options.Patterns)
 		if err != nil {
-			return nil, fmt.Errorf("failed to find targets in %s: %w", pkg, err)
+			return nil, err
 		}
-		targets = append(targets, pkgTargets...)
+		if len(patterns) == 0 {
+			continue
+		}
+
+		moduleOptions := options
+		moduleOptions.RootDir = m.Dir
+		moduleOptions.Patterns = patterns
+
+		found, err := discoverModule(moduleOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover targets of module %s: %w", m.Path, err)
+		}
+		targets = append(targets, found...)
 	}
 
-	// Filter by changed files if requested
-	if options.ChangedOnly {
-		var filteredTargets []*Target
-		for _, t := range targets {
-			changed, err := t.HasChangedSince(options.GitRef)
-			if err != nil {
-				return nil, fmt.Errorf("failed to check changes: %w", err)
-			}
-			if changed {
-				filteredTargets = append(filteredTargets, t)
-			}
+	// Patterns such as "all" match the packages of other workspace modules
+	// as well, so the same target can be found more than once
+	seen := make(map[string]bool)
+	unique := targets[:0]
+	for _, t := range targets {
+		key := t.FilePath + ":" + t.Name
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, t)
 		}
-		targets = filteredTargets
+	}
+	targets = unique
+
+	// Test variants are listed in no particular order
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].Package != targets[j].Package {
+			return targets[i].Package < targets[j].Package
+		}
+		return targets[i].FilePath < targets[j].FilePath
+	})
+
+	// The call graphs are built per module, but targets are ranked against
+	// each other
+	if options.ChangedOnly && options.FunctionImpact {
+		targets = rankByImpact(targets)
 	}
 
 	return targets, nil
 }
 
-// listPackages returns a list of packages matching the given patterns
-func listPackages(rootDir string, patterns []string) ([]string, error) {
-	var pkgs []string
-	for _, pattern := range patterns {
-		cmd := exec.Command("go", "list", pattern)
-		cmd.Dir = rootDir
-		output, err := cmd.Output()
+// discoverModule finds the fuzz targets of the packages matching the
+// patterns when loaded from the root directory, which is a module's
+// directory unless modules aren't used
+func discoverModule(options DiscoveryOptions) ([]*Target, error) {
+	var (
+		targets []*Target
+		err     error
+	)
+	if options.CacheDir != "" {
+		targets, err = cachedTargets(options)
+	} else {
+		targets, err = findTargets(options, options.Patterns)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Only keep targets affected by changes if requested
+	if options.ChangedOnly {
+		targets, err = selectChanged(options, targets)
 		if err != nil {
-			return nil, fmt.Errorf("go list failed: %w", err)
+			return nil, fmt.Errorf("failed to check changes: %w", err)
 		}
 
-		for _, line := range strings.Split(string(output), "\n") {
-			if line = strings.TrimSpace(line); line != "" {
-				pkgs = append(pkgs, line)
+		// Only the targets of changed packages need the call graph
+		if options.FunctionImpact {
+			targets, err = measureImpact(options, targets)
+			if err != nil {
+				return nil, fmt.Errorf("failed to analyze change impact: %w", err)
 			}
 		}
 	}
-	return pkgs, nil
+
+	return targets, nil
 }
 
-// findTargetsInPackage scans a package for fuzz targets
-func findTargetsInPackage(pkg string) ([]*Target, error) {
+// findTargets loads the packages matching the patterns and returns the fuzz
+// targets declared in their tests
+func findTargets(options DiscoveryOptions, patterns []string) ([]*Target, error) {
+	pkgs, err := loadTestPackages(options, loadMode, patterns)
+	if err != nil {
+		return nil, err
+	}
+
 	var targets []*Target
+	for _, pkg := range pkgs {
+		found, err := findTargetsInPackage(pkg)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, found...)
+	}
 
-	// Get package directory
-	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", pkg)
-	output, err := cmd.Output()
+	return targets, nil
+}
+
+// loadTestPackages loads packages together with their test variants from
+// the root directory, honouring the build tags. Any package that fails to
+// load is reported as an error.
+func loadTestPackages(options DiscoveryOptions, mode packages.LoadMode, patterns []string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:       mode,
+		Dir:        options.RootDir,
+		Tests:      true,
+		BuildFlags: buildFlags(options.BuildTags),
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
-		return nil, fmt.Errorf("go list failed: %w", err)
+		return nil, fmt.Errorf("failed to load packages: %w", err)
 	}
 
-	pkgDir := strings.TrimSpace(string(output))
+	var loadErr error
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if len(p.Errors) > 0 && loadErr == nil {
+			loadErr = fmt.Errorf("failed to load %s: %v", p.PkgPath, p.Errors[0])
+		}
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
 
-	// Find all *_test.go files
-	testFiles, err := filepath.Glob(filepath.Join(pkgDir, "*_test.go"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to glob test files: %w", err)
+	return pkgs, nil
+}
+
+// buildFlags turns build tags into go command flags
+func buildFlags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
 	}
+	return []string{"-tags=" + strings.Join(tags, ",")}
+}
 
-	// Parse each test file and look for fuzz targets
-	fset := token.NewFileSet()
-	for _, testFile := range testFiles {
-		file, err := parser.ParseFile(fset, testFile, nil, parser.ParseComments)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse %s: %w", testFile, err)
+// findTargetsInPackage returns the fuzz functions declared in the test files
+// of a test variant of a package. Packages other than test variants, whose
+// files are never compiled into a test binary, have none.
+func findTargetsInPackage(pkg *packages.Package) ([]*Target, error) {
+	if pkg.ForTest == "" {
+		return nil, nil
+	}
+
+	var targets []*Target
+	for _, file := range pkg.Syntax {
+		filename := pkg.Fset.Position(file.Package).Filename
+		if !strings.HasSuffix(filename, "_test.go") {
+			continue
 		}
 
 		for _, decl := range file.Decls {
 			funcDecl, ok := decl.(*ast.FuncDecl)
-			if !ok {
+			if !ok || funcDecl.Recv != nil || !isFuzzName(funcDecl.Name.Name) {
+				continue
+			}
+
+			fn, ok := pkg.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+			if !ok || !isFuzzFunc(fn) {
 				continue
 			}
 
-			// Check if it's a fuzz function (starts with "Fuzz" and accepts *testing.F)
-			if strings.HasPrefix(funcDecl.Name.Name, "Fuzz") && hasFuzzParameter(funcDecl) {
-				target := &Target{
-					Package:  pkg,
-					Name:     funcDecl.Name.Name,
-					FilePath: testFile,
-					FuncName: funcDecl.Name.Name,
-				}
-
-				// Try to extract description from function doc comments
-				if funcDecl.Doc != nil {
-					target.Description = funcDecl.Doc.Text()
-				}
-
-				targets = append(targets, target)
+			// Fuzz functions of external test packages are run through
+			// the package under test
+			target := &Target{
+				Package:  pkg.ForTest,
+				Name:     funcDecl.Name.Name,
+				FilePath: filename,
+				FuncName: funcDecl.Name.Name,
+			}
+
+			// Try to extract description from function doc comments
+			if funcDecl.Doc != nil {
+				target.Description = funcDecl.Doc.Text()
+			}
+
+			annotations, err := parseAnnotations(pkg.Fset, funcDecl.Doc)
+			if err != nil {
+				return nil, err
+			}
+			target.Annotations = annotations
+
+			if pkg.Module != nil {
+				target.Module = pkg.Module.Path
+				target.ModuleDir = pkg.Module.Dir
 			}
+
+			inspectFuzzBody(pkg, funcDecl, target)
+
+			targets = append(targets, target)
 		}
 	}
 
 	return targets, nil
 }
 
-// hasFuzzParameter checks if the function accepts *testing.F
-func hasFuzzParameter(funcDecl *ast.FuncDecl) bool {
-	if funcDecl.Type.Params.List == nil || len(funcDecl.Type.Params.List) != 1 {
+// isFuzzName reports whether go test treats a function name as a fuzz
+// function name: "Fuzz" not followed by a lower case letter
+func isFuzzName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "Fuzz")
+	if !ok {
 		return false
 	}
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(r)
+}
 
-	param := funcDecl.Type.Params.List[0]
-	starExpr, ok := param.Type.(*ast.StarExpr)
-	if !ok {
+// isFuzzFunc checks if the function has the type func(*testing.F), however
+// the testing package was imported
+func isFuzzFunc(fn *types.Func) bool {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Params().Len() != 1 || sig.Results().Len() != 0 || sig.TypeParams().Len() != 0 {
 		return false
 	}
 
-	selectorExpr, ok := starExpr.X.(*ast.SelectorExpr)
+	ptr, ok := sig.Params().At(0).Type().(*types.Pointer)
 	if !ok {
 		return false
 	}
 
-	ident, ok := selectorExpr.X.(*ast.Ident)
+	named, ok := types.Unalias(ptr.Elem()).(*types.Named)
 	if !ok {
 		return false
 	}
 
-	return ident.Name == "testing" && selectorExpr.Sel.Name == "F"
-}
-
-// HasChangedSince determines if a target has changed since the given git reference
-func (t *Target) HasChangedSince(gitRef string) (bool, error) {
-	cmd := exec.Command("git", "diff", "--name-only", gitRef, "--", t.FilePath)
-	output, err := cmd.Output()
-	if err != nil {
-		return false, fmt.Errorf("git diff failed: %w", err)
-	}
-
-	// The file has changed if there's any output
-	if len(output) > 0 {
-		return true, nil
-	}
-
-	// Also check if implementation files have changed
-	// This would require parsing imports in the test file and checking those files
-	// For simplicity, we're not implementing this here
-
-	return false, nil
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "testing" && obj.Name() == "F"
 }
@@ -0,0 +1,189 @@
+// internal/target/discovery_test.go
+package target
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// goEnv isolates the go command from the environment of the test, so
+// temporary modules load without a network or an outer workspace
+func goEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOWORK", "")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOTOOLCHAIN", "local")
+}
+
+// writeFiles writes files, keyed by slash-separated paths, below dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newModule writes the module example.com/m with the given files to a
+// temporary directory and returns it
+func newModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	goEnv(t)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"go.mod": "module example.com/m\n\ngo 1.22\n"})
+	writeFiles(t, dir, files)
+	return dir
+}
+
+// targetNames returns the <package>.<FuzzName> of targets
+func targetNames(targets []*Target) []string {
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.Package+"."+t.Name)
+	}
+	return names
+}
+
+// findTarget returns the target with the given name, failing the test if
+// there is none
+func findTarget(t *testing.T, targets []*Target, name string) *Target {
+	t.Helper()
+	for _, tg := range targets {
+		if tg.Name == name {
+			return tg
+		}
+	}
+	t.Fatalf("target %s not found in %v", name, targetNames(targets))
+	return nil
+}
+
+func TestDiscoverTargets(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "fuzz functions of test files",
+			files: map[string]string{
+				"p/p.go": "package p\n\nimport \"testing\"\n\nfunc FuzzNotATest(f *testing.F) {}\n",
+				"p/p_test.go": `package p
+
+import "testing"
+
+func FuzzA(f *testing.F) {}
+func Fuzz(f *testing.F)  {}
+func Fuzzy(f *testing.F) {}
+
+type s struct{}
+
+func (s) FuzzMethod(f *testing.F) {}
+`,
+			},
+			want: []string{"example.com/m/p.FuzzA", "example.com/m/p.Fuzz"},
+		},
+		{
+			name: "renamed testing import",
+			files: map[string]string{
+				"p/p.go":      "package p\n",
+				"p/p_test.go": "package p\n\nimport tt \"testing\"\n\nfunc FuzzA(f *tt.F) {}\n",
+			},
+			want: []string{"example.com/m/p.FuzzA"},
+		},
+		{
+			name: "alias of testing.F",
+			files: map[string]string{
+				"p/p.go": "package p\n",
+				"p/p_test.go": `package p
+
+import "testing"
+
+type F = testing.F
+
+type fuzzFunc = func(*F)
+
+var _ fuzzFunc = FuzzA
+
+func FuzzA(f *F) {}
+`,
+			},
+			want: []string{"example.com/m/p.FuzzA"},
+		},
+		{
+			name: "external test package runs through the package",
+			files: map[string]string{
+				"p/p.go":      "package p\n",
+				"p/x_test.go": "package p_test\n\nimport \"testing\"\n\nfunc FuzzX(f *testing.F) {}\n",
+			},
+			want: []string{"example.com/m/p.FuzzX"},
+		},
+		{
+			name: "build tags",
+			files: map[string]string{
+				"p/p.go":      "package p\n",
+				"p/p_test.go": "//go:build wire\n\npackage p\n\nimport \"testing\"\n\nfunc FuzzWire(f *testing.F) {}\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newModule(t, tt.files)
+			targets, err := DiscoverTargets(DiscoveryOptions{RootDir: dir, Patterns: []string{"./..."}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := targetNames(targets); !slices.Equal(got, tt.want) {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverTargetsWithTags(t *testing.T) {
+	dir := newModule(t, map[string]string{
+		"p/p.go":      "package p\n",
+		"p/p_test.go": "//go:build wire\n\npackage p\n\nimport \"testing\"\n\nfunc FuzzWire(f *testing.F) {}\n",
+	})
+
+	targets, err := DiscoverTargets(DiscoveryOptions{RootDir: dir, Patterns: []string{"./..."}, BuildTags: []string{"wire"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tg := findTarget(t, targets, "FuzzWire")
+	if tg.Module != "example.com/m" || tg.ModuleDir != dir {
+		t.Errorf("module = %s in %s, want example.com/m in %s", tg.Module, tg.ModuleDir, dir)
+	}
+}
+
+func TestIsFuzzName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Fuzz", true},
+		{"FuzzX", true},
+		{"Fuzz_x", true},
+		{"Fuzz1", true},
+		{"FuzzÉ", true},
+		{"Fuzzy", false},
+		{"Fuzzé", false},
+		{"fuzzX", false},
+		{"TestFuzz", false},
+	}
+
+	for _, tt := range tests {
+		if got := isFuzzName(tt.name); got != tt.want {
+			t.Errorf("isFuzzName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
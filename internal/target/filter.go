@@ -0,0 +1,183 @@
+// internal/target/filter.go
+package target
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// annotationPrefix starts the directives in the doc comment of a fuzz
+// function, e.g. "//fuzz:tags=wire,slow"
+const annotationPrefix = "//fuzz:"
+
+// Annotations are the //fuzz: directives of a fuzz function's doc comment
+type Annotations struct {
+	// Tags from //fuzz:tags=a,b, used to select targets
+	Tags []string
+
+	// Skip is set by //fuzz:skip to leave the target out of all commands
+	Skip bool
+
+	// FuzzTime from //fuzz:time=10m replaces the time allocation of the
+	// target, unless the configuration overrides it
+	FuzzTime time.Duration
+}
+
+// FilterOptions selects targets by name and annotations. Empty options
+// select every target that isn't annotated with //fuzz:skip.
+type FilterOptions struct {
+	// Include and Exclude are regular expressions matched against
+	// "<package>.<FuzzName>". A target is selected if it matches any Include
+	// expression, or there are none, and no Exclude expression.
+	Include []string
+	Exclude []string
+
+	// OnlyTags selects the targets with at least one of the tags, SkipTags
+	// leaves out the targets with any of them
+	OnlyTags []string
+	SkipTags []string
+
+	// Names limits the targets to the given packages and
+	// "<package>.<FuzzName>" targets
+	Names []string
+}
+
+// Filter decides which targets commands work on
+type Filter struct {
+	options FilterOptions
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewFilter compiles filter options
+func NewFilter(options FilterOptions) (*Filter, error) {
+	f := &Filter{options: options}
+
+	var err error
+	if f.include, err = compileAll(options.Include); err != nil {
+		return nil, fmt.Errorf("invalid include filter: %w", err)
+	}
+	if f.exclude, err = compileAll(options.Exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude filter: %w", err)
+	}
+
+	return f, nil
+}
+
+// compileAll compiles regular expressions
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Apply returns the targets the filter selects, in order
+func (f *Filter) Apply(targets []*Target) []*Target {
+	var selected []*Target
+	for _, t := range targets {
+		if f.Match(t) {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
+
+// Match reports whether the filter selects a target
+func (f *Filter) Match(t *Target) bool {
+	if t.Annotations.Skip || !f.MatchName(t.Package, t.Name) {
+		return false
+	}
+
+	if len(f.options.OnlyTags) > 0 && !hasAnyTag(t.Annotations.Tags, f.options.OnlyTags) {
+		return false
+	}
+	return !hasAnyTag(t.Annotations.Tags, f.options.SkipTags)
+}
+
+// MatchName reports whether the name of a target is selected, without
+// looking at its annotations. It is used where only names are known, such
+// as for stored crashes.
+func (f *Filter) MatchName(pkg, name string) bool {
+	full := pkg + "." + name
+
+	if len(f.options.Names) > 0 && !slices.Contains(f.options.Names, pkg) && !slices.Contains(f.options.Names, full) {
+		return false
+	}
+
+	if len(f.include) > 0 && !slices.ContainsFunc(f.include, func(re *regexp.Regexp) bool {
+		return re.MatchString(full)
+	}) {
+		return false
+	}
+
+	return !slices.ContainsFunc(f.exclude, func(re *regexp.Regexp) bool {
+		return re.MatchString(full)
+	})
+}
+
+// UsesTags reports whether the filter selects targets by tag, which needs
+// discovered targets rather than names
+func (f *Filter) UsesTags() bool {
+	return len(f.options.OnlyTags) > 0 || len(f.options.SkipTags) > 0
+}
+
+// hasAnyTag reports whether any of tags is in want
+func hasAnyTag(tags, want []string) bool {
+	return slices.ContainsFunc(tags, func(tag string) bool {
+		return slices.Contains(want, tag)
+	})
+}
+
+// parseAnnotations reads the //fuzz: directives of a doc comment. Unknown
+// directives and invalid values are errors, as they are most likely typos.
+func parseAnnotations(fset *token.FileSet, doc *ast.CommentGroup) (Annotations, error) {
+	var a Annotations
+	if doc == nil {
+		return a, nil
+	}
+
+	for _, c := range doc.List {
+		directive, ok := strings.CutPrefix(c.Text, annotationPrefix)
+		if !ok {
+			continue
+		}
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+
+		switch name {
+		case "tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					a.Tags = append(a.Tags, tag)
+				}
+			}
+
+		case "skip":
+			a.Skip = true
+
+		case "time":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return a, fmt.Errorf("%s: invalid %stime %q, want a positive duration such as 10m",
+					fset.Position(c.Pos()), annotationPrefix, value)
+			}
+			a.FuzzTime = d
+
+		default:
+			return a, fmt.Errorf("%s: unknown annotation %s%s (want tags, skip or time)",
+				fset.Position(c.Pos()), annotationPrefix, name)
+		}
+	}
+
+	return a, nil
+}
@@ -0,0 +1,199 @@
+// internal/target/filter_test.go
+package target
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		annotations Annotations
+		err         string
+	}{
+		{
+			name: "no directives",
+			doc:  "// FuzzX parses things\n",
+		},
+		{
+			name:        "tags",
+			doc:         "// FuzzX parses things\n//\n//fuzz:tags=wire, slow,\n",
+			annotations: Annotations{Tags: []string{"wire", "slow"}},
+		},
+		{
+			name:        "several directives",
+			doc:         "//fuzz:tags=wire\n//fuzz:skip\n//fuzz:time=2m\n",
+			annotations: Annotations{Tags: []string{"wire"}, Skip: true, FuzzTime: 2 * time.Minute},
+		},
+		{
+			name: "directive with a space isn't one",
+			doc:  "// fuzz:skip\n",
+		},
+		{
+			name: "invalid time",
+			doc:  "//fuzz:time=soon\n",
+			err:  "x_test.go:3:1: invalid //fuzz:time \"soon\"",
+		},
+		{
+			name: "negative time",
+			doc:  "//fuzz:time=-1m\n",
+			err:  "invalid //fuzz:time",
+		},
+		{
+			name: "unknown directive",
+			doc:  "// FuzzX parses things\n//fuzz:tag=wire\n",
+			err:  "x_test.go:4:1: unknown annotation //fuzz:tag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package p\n\n" + tt.doc + "func FuzzX() {}\n"
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "x_test.go", src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a, err := parseAnnotations(fset, file.Decls[0].(*ast.FuncDecl).Doc)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(a, tt.annotations) {
+				t.Errorf("annotations = %+v, want %+v", a, tt.annotations)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	targets := []*Target{
+		{Package: "example.com/m/wire", Name: "FuzzMessage", Annotations: Annotations{Tags: []string{"wire"}}},
+		{Package: "example.com/m/wire", Name: "FuzzSlow", Annotations: Annotations{Tags: []string{"wire", "slow"}}},
+		{Package: "example.com/m/tlv", Name: "FuzzDecode"},
+		{Package: "example.com/m/tlv", Name: "FuzzOld", Annotations: Annotations{Skip: true}},
+	}
+
+	tests := []struct {
+		name    string
+		options FilterOptions
+		want    []string
+	}{
+		{
+			name: "everything but skipped targets",
+			want: []string{"FuzzMessage", "FuzzSlow", "FuzzDecode"},
+		},
+		{
+			name:    "only tags",
+			options: FilterOptions{OnlyTags: []string{"wire"}},
+			want:    []string{"FuzzMessage", "FuzzSlow"},
+		},
+		{
+			name:    "skip tags",
+			options: FilterOptions{SkipTags: []string{"slow"}},
+			want:    []string{"FuzzMessage", "FuzzDecode"},
+		},
+		{
+			name:    "skip tags win over only tags",
+			options: FilterOptions{OnlyTags: []string{"wire"}, SkipTags: []string{"slow"}},
+			want:    []string{"FuzzMessage"},
+		},
+		{
+			name:    "include",
+			options: FilterOptions{Include: []string{`/tlv\.`, "Slow$"}},
+			want:    []string{"FuzzSlow", "FuzzDecode"},
+		},
+		{
+			name:    "exclude wins over include",
+			options: FilterOptions{Include: []string{"wire"}, Exclude: []string{"Slow"}},
+			want:    []string{"FuzzMessage"},
+		},
+		{
+			name:    "names of packages and targets",
+			options: FilterOptions{Names: []string{"example.com/m/tlv", "example.com/m/wire.FuzzSlow"}},
+			want:    []string{"FuzzSlow", "FuzzDecode"},
+		},
+		{
+			name:    "skip annotation wins over names",
+			options: FilterOptions{Names: []string{"example.com/m/tlv.FuzzOld"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, tg := range f.Apply(targets) {
+				got = append(got, tg.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewFilter(FilterOptions{Include: []string{"("}}); err == nil {
+		t.Error("invalid include expression accepted")
+	}
+}
+
+func TestDiscoverAnnotations(t *testing.T) {
+	dir := newModule(t, map[string]string{
+		"p/p.go": "package p\n",
+		"p/p_test.go": `package p
+
+import "testing"
+
+// FuzzA parses things
+//
+//fuzz:tags=wire
+//fuzz:time=2m
+func FuzzA(f *testing.F) {}
+
+//fuzz:skip
+func FuzzB(f *testing.F) {}
+`,
+	})
+
+	targets, err := DiscoverTargets(DiscoveryOptions{RootDir: dir, Patterns: []string{"./..."}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := findTarget(t, targets, "FuzzA")
+	if a.Description != "FuzzA parses things\n" {
+		t.Errorf("description = %q, directives aren't left out", a.Description)
+	}
+	if !slices.Equal(a.Annotations.Tags, []string{"wire"}) || a.Annotations.FuzzTime != 2*time.Minute {
+		t.Errorf("annotations of FuzzA = %+v", a.Annotations)
+	}
+	if b := findTarget(t, targets, "FuzzB"); !b.Annotations.Skip {
+		t.Error("FuzzB isn't skipped")
+	}
+
+	// A typo in a directive fails discovery
+	writeFiles(t, dir, map[string]string{
+		"p/p_test.go": "package p\n\nimport \"testing\"\n\n//fuzz:skipp\nfunc FuzzA(f *testing.F) {}\n",
+	})
+	if _, err := DiscoverTargets(DiscoveryOptions{RootDir: dir, Patterns: []string{"./..."}}); err == nil {
+		t.Error("unknown directive accepted")
+	}
+}
@@ -0,0 +1,403 @@
+// internal/target/impact.go
+package target
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// minImpactShare is the smallest share of its time a selected target gets,
+// however little changed code it reaches
+const minImpactShare = 0.1
+
+// maxReasonFunctions is the number of changed functions named in a reason
+const maxReasonFunctions = 3
+
+// Impact describes how much changed code a target can reach
+type Impact struct {
+	// Changed functions the target can reach, most changed lines first.
+	// Changes outside of functions are listed as "<package> declarations".
+	Functions []string
+
+	// Number of changed lines in the reached code
+	Lines int
+
+	// Share is Lines relative to the most affected target, used to scale
+	// the target's time
+	Share float64
+}
+
+// hunkHeader matches the new side of a unified diff hunk, e.g. "@@ -3,2 +4,5 @@"
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// lineRange is an inclusive range of lines
+type lineRange struct {
+	start, end int
+}
+
+// fileChange lists the changed lines of a file
+type fileChange struct {
+	// all is set for files that aren't tracked yet
+	all   bool
+	lines []lineRange
+}
+
+// codeUnit is a function or the package-level declarations of a package,
+// i.e. the unit changed lines are attributed to
+type codeUnit struct {
+	name  string
+	lines int
+}
+
+// measureImpact keeps the targets that can reach code changed since the git
+// reference and sets their Impact. Reachability comes from a class hierarchy
+// analysis call graph of the test binaries, starting at the fuzz functions
+// and the callbacks they pass to f.Fuzz.
+func measureImpact(options DiscoveryOptions, targets []*Target) ([]*Target, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	changes, err := changedLines(options.RootDir, options.GitRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	for _, t := range targets {
+		patterns = append(patterns, t.Package)
+	}
+
+	pkgs, err := loadTestPackages(options, packages.LoadAllSyntax, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, _ := ssautil.AllPackages(pkgs, ssa.InstantiateGenerics)
+	prog.Build()
+	graph := cha.CallGraph(prog)
+
+	units, declUnits := attributeChanges(prog, pkgs, changes)
+
+	var selected []*Target
+	for _, t := range targets {
+		roots := fuzzRoots(prog, t)
+		if len(roots) == 0 {
+			return nil, fmt.Errorf("failed to find %s.%s in the call graph", t.Package, t.Name)
+		}
+
+		impact := reachedChanges(graph, roots, units, declUnits)
+		if impact.Lines == 0 {
+			continue
+		}
+
+		t.Impact = impact
+		selected = append(selected, t)
+	}
+
+	return selected, nil
+}
+
+// rankByImpact orders measured targets most affected first, and sets their
+// Impact.Share and ChangeReason
+func rankByImpact(targets []*Target) []*Target {
+	if len(targets) == 0 {
+		return targets
+	}
+
+	// Scale every target's time by how much changed code it reaches
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Impact.Lines > targets[j].Impact.Lines
+	})
+	for _, t := range targets {
+		t.Impact.Share = max(float64(t.Impact.Lines)/float64(targets[0].Impact.Lines), minImpactShare)
+		t.ChangeReason = impactReason(t.Impact)
+	}
+
+	return targets
+}
+
+// attributeChanges assigns every changed line to the innermost function
+// containing it, or to the package-level declarations of its package. It
+// returns the units of functions, keyed by source position so test variants
+// of a package share them, and the units of declarations keyed by package.
+func attributeChanges(prog *ssa.Program, pkgs []*packages.Package, changes map[string]*fileChange) (map[string]*codeUnit, map[string]*codeUnit) {
+	type span struct {
+		key        string
+		name       string
+		start, end int
+	}
+
+	// Collect the functions declared in changed files
+	spans := make(map[string][]span)
+	for fn := range ssautil.AllFunctions(prog) {
+		syntax := fn.Syntax()
+		if syntax == nil {
+			continue
+		}
+		start := prog.Fset.Position(syntax.Pos())
+		if _, ok := changes[start.Filename]; !ok {
+			continue
+		}
+
+		spans[start.Filename] = append(spans[start.Filename], span{
+			key:   functionKey(prog.Fset, fn),
+			name:  fn.String(),
+			start: start.Line,
+			end:   prog.Fset.Position(syntax.End()).Line,
+		})
+	}
+
+	// Map files to their packages for changes outside of functions
+	filePackages := make(map[string]string)
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, file := range p.CompiledGoFiles {
+			filePackages[file] = p.PkgPath
+		}
+	})
+
+	units := make(map[string]*codeUnit)
+	declUnits := make(map[string]*codeUnit)
+	for file, change := range changes {
+		pkg, ok := filePackages[file]
+		if !ok {
+			continue
+		}
+
+		lines := change.lines
+		if change.all {
+			lines = []lineRange{{1, lastLine(prog.Fset, file)}}
+		}
+
+		for _, r := range lines {
+			for line := r.start; line <= r.end; line++ {
+				// The innermost function is the shortest one containing the line
+				var inner *span
+				for i, s := range spans[file] {
+					if line >= s.start && line <= s.end && (inner == nil || s.end-s.start < inner.end-inner.start) {
+						inner = &spans[file][i]
+					}
+				}
+
+				var u *codeUnit
+				if inner != nil {
+					if u = units[inner.key]; u == nil {
+						u = &codeUnit{name: inner.name}
+						units[inner.key] = u
+					}
+				} else {
+					if u = declUnits[pkg]; u == nil {
+						u = &codeUnit{name: pkg + " declarations"}
+						declUnits[pkg] = u
+					}
+				}
+				u.lines++
+			}
+		}
+	}
+
+	return units, declUnits
+}
+
+// fuzzRoots returns the fuzz function of a target together with the
+// functions declared inside it, such as the callback passed to f.Fuzz, which
+// is called through reflection and therefore has no edge in the call graph
+func fuzzRoots(prog *ssa.Program, t *Target) []*ssa.Function {
+	var roots []*ssa.Function
+	for _, pkg := range prog.AllPackages() {
+		path := pkg.Pkg.Path()
+		if path != t.Package && path != t.Package+"_test" {
+			continue
+		}
+
+		fn := pkg.Func(t.Name)
+		if fn == nil || fn.Syntax() == nil || prog.Fset.Position(fn.Pos()).Filename != t.FilePath {
+			continue
+		}
+
+		var add func(fn *ssa.Function)
+		add = func(fn *ssa.Function) {
+			roots = append(roots, fn)
+			for _, anon := range fn.AnonFuncs {
+				add(anon)
+			}
+		}
+		add(fn)
+	}
+
+	return roots
+}
+
+// reachedChanges walks the call graph from roots and sums up the changes in
+// the functions and packages it reaches
+func reachedChanges(graph *callgraph.Graph, roots []*ssa.Function, units, declUnits map[string]*codeUnit) *Impact {
+	reached := make(map[*codeUnit]bool)
+	visited := make(map[*callgraph.Node]bool)
+
+	var queue []*callgraph.Node
+	for _, fn := range roots {
+		if n := graph.Nodes[fn]; n != nil {
+			queue = append(queue, n)
+			visited[n] = true
+		}
+	}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if fn := n.Func; fn.Syntax() != nil {
+			if u := units[functionKey(fn.Prog.Fset, fn)]; u != nil {
+				reached[u] = true
+			}
+		}
+		if fn := n.Func; fn.Pkg != nil {
+			if u := declUnits[fn.Pkg.Pkg.Path()]; u != nil {
+				reached[u] = true
+			}
+		}
+
+		for _, edge := range n.Out {
+			if !visited[edge.Callee] {
+				visited[edge.Callee] = true
+				queue = append(queue, edge.Callee)
+			}
+		}
+	}
+
+	list := make([]*codeUnit, 0, len(reached))
+	for u := range reached {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].lines != list[j].lines {
+			return list[i].lines > list[j].lines
+		}
+		return list[i].name < list[j].name
+	})
+
+	impact := &Impact{}
+	for _, u := range list {
+		impact.Functions = append(impact.Functions, u.name)
+		impact.Lines += u.lines
+	}
+
+	return impact
+}
+
+// impactReason summarizes an impact for ChangeReason
+func impactReason(impact *Impact) string {
+	names := impact.Functions
+	more := ""
+	if len(names) > maxReasonFunctions {
+		more = fmt.Sprintf(" and %d more", len(names)-maxReasonFunctions)
+		names = names[:maxReasonFunctions]
+	}
+
+	lines := "lines"
+	if impact.Lines == 1 {
+		lines = "line"
+	}
+
+	return fmt.Sprintf("reaches %d changed %s in %s%s",
+		impact.Lines, lines, strings.Join(names, ", "), more)
+}
+
+// functionKey identifies the source of a function across package variants
+func functionKey(fset *token.FileSet, fn *ssa.Function) string {
+	pos := fset.Position(fn.Syntax().Pos())
+	return fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+}
+
+// lastLine returns the number of lines of a parsed file
+func lastLine(fset *token.FileSet, filename string) int {
+	last := 0
+	fset.Iterate(func(f *token.File) bool {
+		if f.Name() == filename {
+			last = f.LineCount()
+			return false
+		}
+		return true
+	})
+	return last
+}
+
+// changedLines parses the hunks of git diff -U0 into the changed lines of
+// every file, keyed by absolute path. Files that aren't tracked yet count as
+// changed entirely.
+func changedLines(rootDir, gitRef string) (map[string]*fileChange, error) {
+	top, err := gitOutput(rootDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top = strings.TrimSpace(top)
+
+	diff, err := gitOutput(rootDir, "diff", "-U0", "--no-color", "--no-ext-diff",
+		"--src-prefix=a/", "--dst-prefix=b/", gitRef, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]*fileChange)
+
+	var current *fileChange
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = nil
+			if name, ok := strings.CutPrefix(line, "+++ b/"); ok {
+				path := filepath.Join(top, filepath.FromSlash(name))
+				current = &fileChange{}
+				changes[path] = current
+			}
+
+		case current != nil && strings.HasPrefix(line, "@@ "):
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+
+			// A pure deletion is reported after the line preceding it, so
+			// count the lines around it as changed
+			if count == 0 {
+				current.lines = append(current.lines, lineRange{max(start, 1), start + 1})
+				continue
+			}
+			current.lines = append(current.lines, lineRange{start, start + count - 1})
+		}
+	}
+
+	untracked, err := gitOutput(rootDir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(untracked, "\n") {
+		if name == "" {
+			continue
+		}
+		changes[filepath.Join(top, filepath.FromSlash(name))] = &fileChange{all: true}
+	}
+
+	return changes, nil
+}
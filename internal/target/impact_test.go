@@ -0,0 +1,144 @@
+// internal/target/impact_test.go
+package target
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// impactModule has targets that reach methods of Shape only through an
+// interface call, and one that doesn't reach them at all
+var impactModule = map[string]string{
+	"p/shape.go": `package p
+
+type Shape interface{ Area() int }
+
+func Total(shapes ...Shape) int {
+	total := 0
+	for _, s := range shapes {
+		total += s.Area()
+	}
+	return total
+}
+`,
+	"p/square.go": `package p
+
+type Square struct{ N int }
+
+func (s Square) Area() int {
+	return s.N * s.N
+}
+`,
+	"p/circle.go": `package p
+
+type Circle struct{ R int }
+
+func (c Circle) Area() int {
+	return 3 * c.R * c.R
+}
+`,
+	"p/other.go": `package p
+
+func Other() int {
+	return 1
+}
+`,
+	"p/p_test.go": `package p
+
+import "testing"
+
+func FuzzTotal(f *testing.F) {
+	f.Fuzz(func(t *testing.T, n int) {
+		Total(Square{N: n})
+	})
+}
+
+func FuzzOther(f *testing.F) {
+	f.Fuzz(func(t *testing.T, n int) {
+		Other()
+	})
+}
+`,
+}
+
+func TestMeasureImpact(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		want    []string
+		funcs   map[string][]string
+		lines   map[string]int
+	}{
+		{
+			name:    "method called through an interface",
+			changes: map[string]string{"p/circle.go": strings.Replace(impactModule["p/circle.go"], "3 *", "314 / 100 *", 1)},
+			want:    []string{"FuzzTotal"},
+			funcs:   map[string][]string{"FuzzTotal": {"(example.com/m/p.Circle).Area"}},
+			lines:   map[string]int{"FuzzTotal": 1},
+		},
+		{
+			name:    "direct call",
+			changes: map[string]string{"p/other.go": strings.Replace(impactModule["p/other.go"], "return 1", "x := 1\n\treturn x", 1)},
+			want:    []string{"FuzzOther"},
+			funcs:   map[string][]string{"FuzzOther": {"example.com/m/p.Other"}},
+			lines:   map[string]int{"FuzzOther": 2},
+		},
+		{
+			name: "most affected first",
+			changes: map[string]string{
+				"p/square.go": strings.Replace(impactModule["p/square.go"], "return s.N * s.N", "n := s.N\n\tn *= s.N\n\treturn n", 1),
+				"p/other.go":  strings.Replace(impactModule["p/other.go"], "return 1", "return 2", 1),
+			},
+			want: []string{"FuzzTotal", "FuzzOther"},
+			funcs: map[string][]string{
+				"FuzzTotal": {"(example.com/m/p.Square).Area"},
+				"FuzzOther": {"example.com/m/p.Other"},
+			},
+			lines: map[string]int{"FuzzTotal": 3, "FuzzOther": 1},
+		},
+		{
+			name:    "unreached function",
+			changes: map[string]string{"p/other.go": impactModule["p/other.go"] + "func Unused() int {\n\treturn 0\n}\n"},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newRepo(t, impactModule)
+			writeFiles(t, dir, tt.changes)
+
+			targets, err := DiscoverTargets(DiscoveryOptions{
+				RootDir:        dir,
+				Patterns:       []string{"./..."},
+				ChangedOnly:    true,
+				GitRef:         "HEAD",
+				FunctionImpact: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, tg := range targets {
+				names = append(names, tg.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("selected %v, want %v", names, tt.want)
+			}
+
+			for _, tg := range targets {
+				if !slices.Equal(tg.Impact.Functions, tt.funcs[tg.Name]) {
+					t.Errorf("%s reaches %v, want %v", tg.Name, tg.Impact.Functions, tt.funcs[tg.Name])
+				}
+				if tg.Impact.Lines != tt.lines[tg.Name] {
+					t.Errorf("%s reaches %d lines, want %d", tg.Name, tg.Impact.Lines, tt.lines[tg.Name])
+				}
+			}
+			if len(targets) > 0 && targets[0].Impact.Share != 1 {
+				t.Errorf("most affected target has share %v, want 1", targets[0].Impact.Share)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+// internal/target/modules.go
+package target
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Module is a Go module fuzz targets are discovered in and run from
+type Module struct {
+	Path string
+	Dir  string
+}
+
+// FindModules returns the modules of the project in rootDir: the modules of
+// its go.work workspace if it has one, otherwise the module rootDir belongs
+// to. With all set and no workspace, every module found below rootDir is
+// returned as well, e.g. independent submodules of a repository. Modules
+// are sorted by directory. Outside of a module, there are none.
+func FindModules(rootDir string, all bool) ([]*Module, error) {
+	env, err := goOutput(rootDir, "env", "GOWORK", "GOMOD")
+	if err != nil {
+		return nil, err
+	}
+	// Unset variables are printed as empty lines
+	gowork, gomod, _ := strings.Cut(env, "\n")
+	gowork = strings.TrimSpace(gowork)
+
+	gomod = strings.TrimSpace(gomod)
+	inModule := gomod != "" && gomod != os.DevNull
+
+	var modules []*Module
+	switch {
+	case gowork != "" && gowork != "off":
+		if modules, err = listModules(rootDir); err != nil {
+			return nil, err
+		}
+
+	case !all:
+		if !inModule {
+			return nil, nil
+		}
+		if modules, err = listModules(rootDir); err != nil {
+			return nil, err
+		}
+
+	default:
+		if modules, err = walkModules(rootDir); err != nil {
+			return nil, err
+		}
+
+		// rootDir may be a directory inside of a module
+		if inModule && ModuleOfDir(modules, filepath.Dir(gomod)) == nil {
+			enclosing, err := listModules(rootDir)
+			if err != nil {
+				return nil, err
+			}
+			modules = append(modules, enclosing...)
+		}
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Dir < modules[j].Dir
+	})
+
+	return modules, nil
+}
+
+// ModuleOf returns the module a package belongs to, i.e. the one with the
+// longest path the package path starts with, or nil if there is none
+func ModuleOf(modules []*Module, pkg string) *Module {
+	var found *Module
+	for _, m := range modules {
+		if withinPath(pkg, m.Path, "/") && (found == nil || len(m.Path) > len(found.Path)) {
+			found = m
+		}
+	}
+	return found
+}
+
+// ModuleOfDir returns the module a directory belongs to, i.e. the innermost
+// module containing it, or nil if there is none
+func ModuleOfDir(modules []*Module, dir string) *Module {
+	var found *Module
+	for _, m := range modules {
+		if withinPath(dir, m.Dir, string(filepath.Separator)) && (found == nil || len(m.Dir) > len(found.Dir)) {
+			found = m
+		}
+	}
+	return found
+}
+
+// modulePatterns translates package patterns given relative to rootDir into
+// the patterns matching the same packages of module m when run from its
+// directory. It returns nil if none of the patterns match packages of m.
+func modulePatterns(m *Module, modules []*Module, rootDir string, patterns []string) ([]string, error) {
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+
+	var translated []string
+	for _, pattern := range patterns {
+		if !isFilePattern(pattern) {
+			if p, ok := importPattern(m, modules, pattern); ok {
+				translated = append(translated, p)
+			}
+			continue
+		}
+
+		dir := filepath.FromSlash(pattern)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		base, recursive := strings.CutSuffix(filepath.ToSlash(dir), "/...")
+		base = filepath.FromSlash(base)
+
+		switch {
+		case recursive && withinPath(m.Dir, base, string(filepath.Separator)):
+			// The whole module is below the pattern's directory
+			translated = append(translated, "./...")
+
+		case ModuleOfDir(modules, base) == m:
+			rel, err := filepath.Rel(m.Dir, base)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve pattern %s: %w", pattern, err)
+			}
+			p := "./" + filepath.ToSlash(rel)
+			if rel == "." {
+				p = "."
+			}
+			if recursive {
+				p += "/..."
+			}
+			translated = append(translated, p)
+		}
+	}
+
+	return translated, nil
+}
+
+// importPattern returns the part of an import path pattern that matches
+// packages of module m
+func importPattern(m *Module, modules []*Module, pattern string) (string, bool) {
+	base, recursive := strings.CutSuffix(pattern, "/...")
+	switch {
+	case pattern == "all" || pattern == "...":
+		return pattern, true
+	case recursive && withinPath(m.Path, base, "/"):
+		return m.Path + "/...", true
+	case ModuleOf(modules, base) == m:
+		return pattern, true
+	}
+	return "", false
+}
+
+// isFilePattern reports whether a package pattern is a directory rather
+// than an import path
+func isFilePattern(pattern string) bool {
+	return pattern == "." || pattern == ".." || strings.HasPrefix(pattern, "./") ||
+		strings.HasPrefix(pattern, "../") || filepath.IsAbs(pattern)
+}
+
+// withinPath reports whether path is base or below it
+func withinPath(path, base, sep string) bool {
+	return path == base || strings.HasPrefix(path, strings.TrimSuffix(base, sep)+sep)
+}
+
+// listModules lists the main modules with go list -m, i.e. the modules of
+// the workspace or the module of dir
+func listModules(dir string) ([]*Module, error) {
+	output, err := goOutput(dir, "list", "-m", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []*Module
+	dec := json.NewDecoder(strings.NewReader(output))
+	for {
+		m := &Module{}
+		if err := dec.Decode(m); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		modules = append(modules, m)
+	}
+
+	return modules, nil
+}
+
+// walkModules finds the go.mod files below dir, skipping the directories
+// the go command ignores
+func walkModules(dir string) ([]*Module, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+
+	var modules []*Module
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "testdata" || name == "vendor" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		modPath := modfile.ModulePath(data)
+		if modPath == "" {
+			return fmt.Errorf("no module path in %s", path)
+		}
+
+		modules = append(modules, &Module{Path: modPath, Dir: filepath.Dir(path)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find modules: %w", err)
+	}
+
+	return modules, nil
+}
+
+// goOutput runs the go command in dir and returns its output
+func goOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(output), nil
+}
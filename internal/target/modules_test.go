@@ -0,0 +1,192 @@
+// internal/target/modules_test.go
+package target
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newWorkspace writes a go.work with the modules example.com/a in a and
+// example.com/b in b, and the module example.com/c in c outside of the
+// workspace, and returns its directory
+func newWorkspace(t *testing.T, work bool) string {
+	t.Helper()
+	goEnv(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"a/go.mod":          "module example.com/a\n\ngo 1.22\n",
+		"a/a.go":            "package a\n",
+		"a/a_test.go":       "package a\n\nimport \"testing\"\n\nfunc FuzzA(f *testing.F) {}\n",
+		"b/go.mod":          "module example.com/b\n\ngo 1.22\n",
+		"b/sub/b.go":        "package sub\n",
+		"b/sub/b_test.go":   "package sub\n\nimport \"testing\"\n\nfunc FuzzB(f *testing.F) {}\n",
+		"c/go.mod":          "module example.com/c\n\ngo 1.22\n",
+		"c/c_test.go":       "package c\n\nimport \"testing\"\n\nfunc FuzzC(f *testing.F) {}\n",
+		"c/testdata/go.mod": "module example.com/ignored\n",
+	}
+	if work {
+		files["go.work"] = "go 1.22\n\nuse (\n\t./a\n\t./b\n)\n"
+	}
+	writeFiles(t, dir, files)
+
+	return dir
+}
+
+// modulePaths returns the paths of modules
+func modulePaths(modules []*Module) []string {
+	var paths []string
+	for _, m := range modules {
+		paths = append(paths, m.Path)
+	}
+	return paths
+}
+
+func TestFindModules(t *testing.T) {
+	tests := []struct {
+		name string
+		work bool
+		dir  string
+		all  bool
+		want []string
+	}{
+		{"workspace", true, ".", false, []string{"example.com/a", "example.com/b"}},
+		{"workspace from a module", true, "b/sub", false, []string{"example.com/a", "example.com/b"}},
+		{"enclosing module only", false, "b/sub", false, []string{"example.com/b"}},
+		{"no module", false, ".", false, nil},
+		{"all modules below", false, ".", true, []string{"example.com/a", "example.com/b", "example.com/c"}},
+		{"all modules with the enclosing one", false, "b/sub", true, []string{"example.com/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newWorkspace(t, tt.work)
+			modules, err := FindModules(filepath.Join(root, tt.dir), tt.all)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := modulePaths(modules); !slices.Equal(got, tt.want) {
+				t.Errorf("modules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		work     bool
+		all      bool
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "every module of the workspace",
+			work:     true,
+			patterns: []string{"./..."},
+			want:     []string{"example.com/a.FuzzA", "example.com/b/sub.FuzzB"},
+		},
+		{
+			name:     "directory of one module",
+			work:     true,
+			patterns: []string{"./b/..."},
+			want:     []string{"example.com/b/sub.FuzzB"},
+		},
+		{
+			name:     "import path of one module",
+			work:     true,
+			patterns: []string{"example.com/a"},
+			want:     []string{"example.com/a.FuzzA"},
+		},
+		{
+			name:     "all modules without a workspace",
+			all:      true,
+			patterns: []string{"./..."},
+			want:     []string{"example.com/a.FuzzA", "example.com/b/sub.FuzzB", "example.com/c.FuzzC"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newWorkspace(t, tt.work)
+			targets, err := DiscoverTargets(DiscoveryOptions{RootDir: root, Patterns: tt.patterns, AllModules: tt.all})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := targetNames(targets); !slices.Equal(got, tt.want) {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+			for _, tg := range targets {
+				if filepath.Dir(tg.FilePath) != tg.ModuleDir && filepath.Dir(filepath.Dir(tg.FilePath)) != tg.ModuleDir {
+					t.Errorf("%s is in %s, outside of its module %s", tg.Name, tg.FilePath, tg.ModuleDir)
+				}
+			}
+		})
+	}
+}
+
+func TestModulePatterns(t *testing.T) {
+	root := filepath.FromSlash("/src")
+	a := &Module{Path: "example.com/a", Dir: filepath.Join(root, "a")}
+	b := &Module{Path: "example.com/a/b", Dir: filepath.Join(root, "a", "b")}
+	modules := []*Module{a, b}
+
+	tests := []struct {
+		name     string
+		module   *Module
+		patterns []string
+		want     []string
+	}{
+		{"whole tree", a, []string{"./..."}, []string{"./..."}},
+		{"directory of the module", a, []string{"./a/x"}, []string{"./x"}},
+		{"module root", a, []string{"./a"}, []string{"."}},
+		{"recursive below the module", a, []string{"./a/x/..."}, []string{"./x/..."}},
+		{"nested module's directory", a, []string{"./a/b/y"}, nil},
+		{"nested module", b, []string{"./a/b/y"}, []string{"./y"}},
+		{"other directory", a, []string{"./c"}, nil},
+		{"import path", a, []string{"example.com/a/x"}, []string{"example.com/a/x"}},
+		{"import path of the nested module", a, []string{"example.com/a/b/y"}, nil},
+		{"recursive import path", b, []string{"example.com/a/..."}, []string{"example.com/a/b/..."}},
+		{"all", b, []string{"all"}, []string{"all"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := modulePatterns(tt.module, modules, root, tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("patterns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleOf(t *testing.T) {
+	modules := []*Module{
+		{Path: "example.com/a", Dir: filepath.FromSlash("/src/a")},
+		{Path: "example.com/a/b", Dir: filepath.FromSlash("/src/a/b")},
+	}
+
+	tests := []struct {
+		pkg, dir string
+		want     string
+	}{
+		{"example.com/a", "/src/a", "example.com/a"},
+		{"example.com/a/x", "/src/a/x", "example.com/a"},
+		{"example.com/a/b/y", "/src/a/b/y", "example.com/a/b"},
+		{"example.com/ab", "/src/ab", ""},
+		{"example.com/c", "/src", ""},
+	}
+
+	for _, tt := range tests {
+		if m := ModuleOf(modules, tt.pkg); (m == nil && tt.want != "") || (m != nil && m.Path != tt.want) {
+			t.Errorf("ModuleOf(%s) = %v, want %s", tt.pkg, m, tt.want)
+		}
+		if m := ModuleOfDir(modules, filepath.FromSlash(tt.dir)); (m == nil && tt.want != "") || (m != nil && m.Path != tt.want) {
+			t.Errorf("ModuleOfDir(%s) = %v, want %s", tt.dir, m, tt.want)
+		}
+	}
+}
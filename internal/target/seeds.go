@@ -0,0 +1,128 @@
+// internal/target/seeds.go
+package target
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// inspectFuzzBody records the fuzz argument types and the f.Add seeds of a
+// fuzz function
+func inspectFuzzBody(pkg *packages.Package, decl *ast.FuncDecl, t *Target) {
+	if decl.Body == nil {
+		return
+	}
+
+	qualifier := types.RelativeTo(pkg.Types)
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		switch fuzzMethod(pkg.TypesInfo, call) {
+		case "Fuzz":
+			if len(call.Args) != 1 || t.Params != nil {
+				break
+			}
+			sig, ok := pkg.TypesInfo.TypeOf(call.Args[0]).Underlying().(*types.Signature)
+			if !ok {
+				break
+			}
+
+			// The first parameter is the *testing.T
+			t.Params = []string{}
+			for i := 1; i < sig.Params().Len(); i++ {
+				t.Params = append(t.Params, types.TypeString(sig.Params().At(i).Type(), qualifier))
+			}
+
+		case "Add":
+			seed := Seed{
+				Static: call.Ellipsis == 0,
+				Line:   pkg.Fset.Position(call.Pos()).Line,
+			}
+			for _, arg := range call.Args {
+				value, ok := seedValue(pkg.TypesInfo, arg, qualifier)
+				if !ok {
+					value = types.ExprString(arg)
+					seed.Static = false
+				}
+				seed.Values = append(seed.Values, value)
+			}
+			t.Seeds = append(t.Seeds, seed)
+		}
+
+		return true
+	})
+}
+
+// fuzzMethod returns the name of the *testing.F method a call invokes, if any
+func fuzzMethod(info *types.Info, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok {
+		return ""
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	if ptr, ok := recv.Type().(*types.Pointer); ok {
+		if named, ok := ptr.Elem().(*types.Named); ok {
+			obj := named.Obj()
+			if obj.Pkg() != nil && obj.Pkg().Path() == "testing" && obj.Name() == "F" {
+				return fn.Name()
+			}
+		}
+	}
+
+	return ""
+}
+
+// seedValue formats a seed argument in the corpus file format if its value
+// is known at compile time, i.e. it is a constant or a conversion of one
+// such as []byte("abc")
+func seedValue(info *types.Info, expr ast.Expr, qualifier types.Qualifier) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok {
+		return "", false
+	}
+	typ := types.TypeString(tv.Type, qualifier)
+
+	if tv.Value != nil {
+		return typ + "(" + constantString(tv.Value) + ")", true
+	}
+
+	// []byte("abc") is a conversion of a constant
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || !info.Types[call.Fun].IsType() {
+		return "", false
+	}
+	if arg := info.Types[call.Args[0]]; arg.Value != nil && arg.Value.Kind() == constant.String {
+		return typ + "(" + constantString(arg.Value) + ")", true
+	}
+
+	return "", false
+}
+
+// constantString formats a constant as a Go literal
+func constantString(v constant.Value) string {
+	switch v.Kind() {
+	case constant.String:
+		return strconv.Quote(constant.StringVal(v))
+	case constant.Float:
+		f, _ := constant.Float64Val(v)
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	default:
+		return v.ExactString()
+	}
+}
@@ -0,0 +1,119 @@
+// internal/target/seeds_test.go
+package target
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestInspectFuzzBody(t *testing.T) {
+	dir := newModule(t, map[string]string{
+		"p/p.go": "package p\n\ntype Kind uint8\n\nconst Magic = \"MAGIC\"\n",
+		"p/p_test.go": `package p
+
+import (
+	"testing"
+	tt "testing"
+)
+
+const greeting = "hello"
+
+func FuzzSeeds(f *testing.F) {
+	f.Add([]byte("abc"), 5, "x")
+	f.Add([]byte(greeting+"!"), -1, Magic)
+	f.Add([]byte{1, 2}, len(greeting), "y")
+	for _, s := range []string{"a", "b"} {
+		f.Add([]byte(s), 0, s)
+	}
+	f.Fuzz(func(t *testing.T, b []byte, n int, s string) {})
+}
+
+func FuzzTypes(f *tt.F) {
+	f.Add(uint8(7), Kind(3), 1.5, 'x', true)
+	f.Fuzz(func(t *tt.T, a byte, k Kind, x float64, r rune, ok bool) {})
+}
+
+func FuzzNoCallback(f *testing.F) {}
+
+func FuzzVariadic(f *testing.F) {
+	args := []any{[]byte("a")}
+	f.Add(args...)
+	f.Fuzz(func(t *testing.T, b []byte) {})
+}
+`,
+		"q/q_test.go": `package q_test
+
+import (
+	"testing"
+
+	"example.com/m/p"
+)
+
+func FuzzExternal(f *testing.F) {
+	f.Add(uint8(p.Kind(1)))
+	f.Fuzz(func(t *testing.T, k p.Kind) {})
+}
+`,
+		"q/q.go": "package q\n",
+	})
+
+	targets, err := DiscoverTargets(DiscoveryOptions{RootDir: dir, Patterns: []string{"./..."}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		params []string
+		seeds  []Seed
+	}{
+		{
+			name:   "FuzzSeeds",
+			params: []string{"[]byte", "int", "string"},
+			seeds: []Seed{
+				{Values: []string{`[]byte("abc")`, "int(5)", `string("x")`}, Static: true, Line: 11},
+				{Values: []string{`[]byte("hello!")`, "int(-1)", `string("MAGIC")`}, Static: true, Line: 12},
+				{Values: []string{"[]byte{…}", "int(5)", `string("y")`}, Static: false, Line: 13},
+				{Values: []string{"[]byte(s)", "int(0)", "s"}, Static: false, Line: 15},
+			},
+		},
+		{
+			name:   "FuzzTypes",
+			params: []string{"byte", "Kind", "float64", "rune", "bool"},
+			seeds: []Seed{
+				{Values: []string{"uint8(7)", "Kind(3)", "float64(1.5)", "rune(120)", "bool(true)"}, Static: true, Line: 21},
+			},
+		},
+		{
+			name: "FuzzNoCallback",
+		},
+		{
+			name:   "FuzzVariadic",
+			params: []string{"[]byte"},
+			seeds:  []Seed{{Values: []string{"args"}, Static: false, Line: 29}},
+		},
+		{
+			name:   "FuzzExternal",
+			params: []string{"example.com/m/p.Kind"},
+			seeds:  []Seed{{Values: []string{"uint8(1)"}, Static: true, Line: 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tg := findTarget(t, targets, tt.name)
+			if !slices.Equal(tg.Params, tt.params) || (tg.Params == nil) != (tt.params == nil) {
+				t.Errorf("params = %#v, want %#v", tg.Params, tt.params)
+			}
+			if len(tg.Seeds) != len(tt.seeds) {
+				t.Fatalf("seeds = %+v, want %+v", tg.Seeds, tt.seeds)
+			}
+			for i, seed := range tg.Seeds {
+				want := tt.seeds[i]
+				if !slices.Equal(seed.Values, want.Values) || seed.Static != want.Static || seed.Line != want.Line {
+					t.Errorf("seed %d = %+v, want %+v", i, seed, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// pkg/config/allocation.go
+package config
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of time allocation keys, from least to most specific
+const (
+	matchNone = iota
+	matchDefault
+	matchSuffix
+	matchPattern
+	matchExact
+)
+
+// Allocation returns the time allocation key and weight that apply to a
+// target. Keys may name a package by import path suffix ("lnwire",
+// "lnd/lnwire"), by pattern ("github.com/lightningnetwork/lnd/lnwire/...")
+// or by exact import path, and may be followed by ".<FuzzName>" to address
+// a single target. The most specific key wins: target keys beat package
+// keys, exact paths beat patterns, patterns beat suffixes, and longer keys
+// beat shorter ones of the same kind. "default" applies when nothing else
+// matches. Without any matching key the target gets the full fuzz time.
+func (c *Config) Allocation(pkg, name string) (string, float64) {
+	bestKey, bestTarget, bestKind := "", false, matchNone
+	for key := range c.TimeAllocation {
+		isTarget, kind := matchAllocationKey(key, pkg, name)
+		if kind == matchNone {
+			continue
+		}
+
+		better := false
+		switch {
+		case isTarget != bestTarget:
+			better = isTarget
+		case kind != bestKind:
+			better = kind > bestKind
+		default:
+			// Break ties deterministically, preferring longer keys
+			better = len(key) > len(bestKey) || (len(key) == len(bestKey) && key < bestKey)
+		}
+
+		if bestKind == matchNone || better {
+			bestKey, bestTarget, bestKind = key, isTarget, kind
+		}
+	}
+
+	if bestKind == matchNone {
+		return "", 1.0
+	}
+
+	return bestKey, c.TimeAllocation[bestKey]
+}
+
+// matchAllocationKey reports whether an allocation key applies to a target,
+// whether it addresses the target itself, and how specific the match is
+func matchAllocationKey(key, pkg, name string) (bool, int) {
+	if key == "default" {
+		return false, matchDefault
+	}
+
+	if isTargetKey(key) {
+		i := strings.LastIndex(key, ".")
+		if key[i+1:] != name {
+			return true, matchNone
+		}
+		return true, matchPackage(key[:i], pkg)
+	}
+
+	return false, matchPackage(key, pkg)
+}
+
+// matchPackage matches a package key against an import path
+func matchPackage(key, pkg string) int {
+	switch {
+	case key == pkg:
+		return matchExact
+	case strings.Contains(key, "..."):
+		if patternRegexp(key).MatchString(pkg) {
+			return matchPattern
+		}
+	case strings.HasSuffix(pkg, "/"+key):
+		return matchSuffix
+	}
+	return matchNone
+}
+
+// patternRegexp converts a go list style pattern, in which "..." matches any
+// string and a trailing "/..." also matches the directory itself, into a
+// regular expression
+func patternRegexp(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(pattern)
+	if strings.HasSuffix(expr, `/\.\.\.`) {
+		expr = strings.TrimSuffix(expr, `/\.\.\.`) + `(/\.\.\.)?`
+	}
+	expr = strings.ReplaceAll(expr, `\.\.\.`, `.*`)
+
+	return regexp.MustCompile("^" + expr + "$")
+}
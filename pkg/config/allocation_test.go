@@ -0,0 +1,195 @@
+// pkg/config/allocation_test.go
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllocation(t *testing.T) {
+	const lnwire = "github.com/lightningnetwork/lnd/lnwire"
+
+	tests := []struct {
+		name       string
+		allocation map[string]float64
+		pkg        string
+		target     string
+		key        string
+		weight     float64
+	}{
+		{
+			name:   "no keys",
+			pkg:    lnwire,
+			target: "FuzzMessage",
+			weight: 1,
+		},
+		{
+			name:       "default",
+			allocation: map[string]float64{"default": 0.05, "tlv": 0.1},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        "default",
+			weight:     0.05,
+		},
+		{
+			name:       "suffix beats default",
+			allocation: map[string]float64{"default": 0.05, "lnwire": 0.4},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        "lnwire",
+			weight:     0.4,
+		},
+		{
+			name:       "suffix matches whole path elements",
+			allocation: map[string]float64{"wire": 0.4},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			weight:     1,
+		},
+		{
+			name:       "longer suffix beats shorter",
+			allocation: map[string]float64{"lnwire": 0.4, "lnd/lnwire": 0.2},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        "lnd/lnwire",
+			weight:     0.2,
+		},
+		{
+			name:       "pattern beats suffix",
+			allocation: map[string]float64{"lnwire": 0.4, "github.com/lightningnetwork/lnd/...": 0.3},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        "github.com/lightningnetwork/lnd/...",
+			weight:     0.3,
+		},
+		{
+			name:       "trailing pattern matches the directory itself",
+			allocation: map[string]float64{lnwire + "/...": 0.3},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        lnwire + "/...",
+			weight:     0.3,
+		},
+		{
+			name:       "pattern doesn't match other packages",
+			allocation: map[string]float64{"github.com/lightningnetwork/lnd/tlv/...": 0.3},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			weight:     1,
+		},
+		{
+			name:       "exact path beats pattern",
+			allocation: map[string]float64{lnwire: 0.6, "github.com/lightningnetwork/...": 0.3},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        lnwire,
+			weight:     0.6,
+		},
+		{
+			name:       "target key beats exact package",
+			allocation: map[string]float64{lnwire: 0.6, "lnwire.FuzzMessage": 0.9},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        "lnwire.FuzzMessage",
+			weight:     0.9,
+		},
+		{
+			name:       "target key of another target",
+			allocation: map[string]float64{"lnwire": 0.4, "lnwire.FuzzOther": 0.9},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        "lnwire",
+			weight:     0.4,
+		},
+		{
+			name:       "exact target key beats suffix target key",
+			allocation: map[string]float64{"lnwire.FuzzMessage": 0.9, lnwire + ".FuzzMessage": 0.7},
+			pkg:        lnwire,
+			target:     "FuzzMessage",
+			key:        lnwire + ".FuzzMessage",
+			weight:     0.7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{TimeAllocation: tt.allocation}
+			key, weight := c.Allocation(tt.pkg, tt.target)
+			if key != tt.key || weight != tt.weight {
+				t.Errorf("Allocation = %q, %g, want %q, %g", key, weight, tt.key, tt.weight)
+			}
+		})
+	}
+}
+
+func TestOverAllocation(t *testing.T) {
+	targets := []string{
+		"example.com/fz.FuzzParse",
+		"example.com/fz.FuzzOther",
+		"example.com/wire.FuzzMessage",
+		"example.com/wire.FuzzDecode",
+	}
+
+	tests := []struct {
+		name       string
+		allocation map[string]float64
+		targets    []string
+		over       string
+	}{
+		{
+			name:       "keys shared by targets count once",
+			allocation: map[string]float64{"fz": 0.5, "wire": 0.5},
+			targets:    targets,
+		},
+		{
+			name:       "overridden package key doesn't count",
+			allocation: map[string]float64{"fz": 0.5, "fz.FuzzParse": 0.9},
+			targets:    targets[:1],
+		},
+		{
+			name:       "unused keys don't count",
+			allocation: map[string]float64{"tlv": 0.9, "wire": 0.5},
+			targets:    targets,
+		},
+		{
+			name:       "package and target key both used",
+			allocation: map[string]float64{"fz": 0.5, "fz.FuzzParse": 0.9},
+			targets:    targets,
+			over:       "1.4",
+		},
+		{
+			name:       "default counts once",
+			allocation: map[string]float64{"default": 0.3, "wire": 0.8},
+			targets:    targets,
+			over:       "1.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{TimeAllocation: tt.allocation}
+			err := c.OverAllocation(tt.targets)
+			switch {
+			case tt.over == "" && err != nil:
+				t.Errorf("unexpected warning: %v", err)
+			case tt.over != "" && err == nil:
+				t.Errorf("no warning, want fractions adding up to %s", tt.over)
+			case tt.over != "" && !strings.Contains(err.Error(), "add up to "+tt.over+","):
+				t.Errorf("warning %q, want fractions adding up to %s", err, tt.over)
+			}
+		})
+	}
+}
+
+func TestValidateAllowsOverrides(t *testing.T) {
+	c := Default()
+	c.TimeAllocation = map[string]float64{"fz": 0.5, "fz.FuzzParse": 0.9}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	c.TimeAllocation["fz"] = -0.5
+	if err := c.Validate(); err == nil {
+		t.Error("negative fraction accepted")
+	}
+}
@@ -1,60 +1,206 @@
 // pkg/config/config.go
 package config
 
-import "time"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Time allocation strategies
+const (
+	// StrategyFixed gives every target its time allocation in one go
+	StrategyFixed = "fixed"
+
+	// StrategyAdaptive runs targets in short rounds and gives more rounds to
+	// the targets that keep finding new coverage
+	StrategyAdaptive = "adaptive"
+)
+
+// Change impact analyses
+const (
+	// ImpactPackage selects targets whose package or its dependencies changed
+	ImpactPackage = "package"
+
+	// ImpactFunction selects targets that can call changed functions and
+	// gives them time by how much changed code they reach
+	ImpactFunction = "function"
+)
 
 // Config represents the main configuration for the fuzzing runner
 type Config struct {
 	// Packages to scan for fuzz targets
-	Packages []string
+	Packages []string `yaml:"packages"`
 
 	// Root directory of the project
-	RootDir string
+	RootDir string `yaml:"root_dir"`
+
+	// Whether targets are discovered in every module below RootDir when it
+	// isn't part of a go.work workspace, rather than only in its own module
+	AllModules bool `yaml:"all_modules"`
+
+	// Build tags used to discover, build and run fuzz targets
+	BuildTags []string `yaml:"build_tags"`
 
 	// Directory to store corpus files
-	CorpusDir string
+	CorpusDir string `yaml:"corpus_dir"`
+
+	// Directory to store failing inputs found while fuzzing. When empty,
+	// they are stored in the crashers directory of CorpusDir.
+	CrashDir string `yaml:"crash_dir"`
+
+	// Whether line numbers are part of crash signatures when bucketing crashes
+	CrashSignatureLines bool `yaml:"crash_signature_lines"`
 
 	// Max time to spend on each fuzz target
-	FuzzTime time.Duration
+	FuzzTime time.Duration `yaml:"fuzz_time"`
+
+	// Total wall-clock time for the whole run. When set, it is split across
+	// all targets by their time allocation weights and FuzzTime is ignored.
+	Budget time.Duration `yaml:"budget"`
 
-	// Number of parallel processes to use
-	Parallelism int
+	// How time is allocated across targets, StrategyFixed or StrategyAdaptive
+	Strategy string `yaml:"strategy"`
 
-	// Whether to use auto-discovery of harnesses
-	HarnessDetection bool
+	// Length of a round of the adaptive strategy
+	RoundTime time.Duration `yaml:"round_time"`
+
+	// Number of parallel processes to use across all running targets
+	Parallelism int `yaml:"parallelism"`
+
+	// Number of fuzz targets to run at the same time (0 derives it from Parallelism)
+	ConcurrentTargets int `yaml:"concurrent_targets"`
+
+	// Whether discovered targets are cached in the user cache directory
+	// until the files of their package change
+	DiscoveryCache bool `yaml:"discovery_cache"`
+
+	// Whether fuzzing-instrumented test binaries are kept in the user cache
+	// directory and reused until the sources they are built from change
+	BinaryCache bool `yaml:"binary_cache"`
+
+	// Whether to use auto-discovery of harnesses. When disabled, only the
+	// targets listed in Targets are used.
+	HarnessDetection bool `yaml:"harness_detection"`
 
 	// Time allocation strategy based on package importance
-	TimeAllocation map[string]float64
+	TimeAllocation map[string]float64 `yaml:"time_allocation"`
 
 	// Report output directory
-	ReportDir string
+	ReportDir string `yaml:"report_dir"`
 
 	// Only fuzz targets affected by recent changes
-	ChangedOnly bool
+	ChangedOnly bool `yaml:"changed_only"`
 
 	// Git reference to compare against for changes
-	GitRef string
+	GitRef string `yaml:"git_ref"`
+
+	// How changes select targets, ImpactPackage or ImpactFunction
+	Impact string `yaml:"impact"`
+
+	// Regular expressions matched against "<package>.<FuzzName>" to select
+	// targets. Exclude wins over Include.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+
+	// Only use targets with one of these //fuzz:tags, and leave out the
+	// ones with any of SkipTags
+	OnlyTags []string `yaml:"only_tags"`
+	SkipTags []string `yaml:"skip_tags"`
+
+	// Per-target overrides, keyed by "<package>.<FuzzName>"
+	Targets map[string]TargetConfig `yaml:"targets"`
+
+	// Where the configuration was loaded from, for error messages
+	source *source
+}
+
+// TargetConfig overrides settings for a single fuzz target
+type TargetConfig struct {
+	// Time to spend on the target, replacing the time allocation
+	FuzzTime time.Duration `yaml:"fuzz_time"`
+
+	// Leave the target out of all commands
+	Skip bool `yaml:"skip"`
+}
+
+// crashSubdir is where failing inputs are stored in the corpus directory
+// unless CrashDir is set
+const crashSubdir = "crashers"
+
+// CrashPath returns the directory of stored failing inputs
+func (c *Config) CrashPath() string {
+	if c.CrashDir != "" {
+		return c.CrashDir
+	}
+	return filepath.Join(c.CorpusDir, crashSubdir)
 }
 
 // Default returns a default configuration
 func Default() *Config {
 	return &Config{
-		Packages:         []string{"./..."},
-		RootDir:          ".",
-		CorpusDir:        "./fuzz-corpus",
-		FuzzTime:         5 * time.Minute,
-		Parallelism:      4,
-		HarnessDetection: true,
-		TimeAllocation:   map[string]float64{"default": 1.0},
-		ReportDir:        "./fuzz-reports",
-		ChangedOnly:      false,
-		GitRef:           "HEAD~1",
+		Packages:          []string{"./..."},
+		RootDir:           ".",
+		CorpusDir:         "./fuzz-corpus",
+		FuzzTime:          5 * time.Minute,
+		Strategy:          StrategyFixed,
+		RoundTime:         30 * time.Second,
+		Parallelism:       4,
+		ConcurrentTargets: 0,
+		DiscoveryCache:    true,
+		BinaryCache:       true,
+		HarnessDetection:  true,
+		TimeAllocation:    map[string]float64{"default": 1.0},
+		ReportDir:         "./fuzz-reports",
+		ChangedOnly:       false,
+		GitRef:            "HEAD~1",
+		Impact:            ImpactPackage,
 	}
 }
 
-// LoadFromFile loads configuration from a file
+// LoadFromFile loads configuration from a YAML or JSON file. Settings not
+// present in the file keep their default values.
 func LoadFromFile(path string) (*Config, error) {
-	// Implementation would parse YAML/JSON configuration file
-	// For brevity, returning default config
-	return Default(), nil
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := Default()
+
+	// Maps are merged by the decoder, so drop the default allocation to
+	// let a configured one replace it entirely
+	cfg.TimeAllocation = nil
+
+	// JSON is a subset of YAML, so one decoder handles both formats
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if cfg.TimeAllocation == nil {
+		cfg.TimeAllocation = Default().TimeAllocation
+	}
+
+	// Remember where each setting is so validation errors can point at it
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err == nil {
+		cfg.source = &source{path: path, lines: make(map[string]int)}
+		recordLines(&doc, "", cfg.source.lines)
+	}
+
+	return cfg, nil
+}
+
+// Target returns the overrides for a target, if any
+func (c *Config) Target(pkg, name string) (TargetConfig, bool) {
+	tc, ok := c.Targets[pkg+"."+name]
+	return tc, ok
 }
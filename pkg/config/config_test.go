@@ -0,0 +1,31 @@
+// pkg/config/config_test.go
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCrashPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		corpusDir string
+		crashDir  string
+		want      string
+	}{
+		{"default", "./fuzz-corpus", "", filepath.Join("fuzz-corpus", "crashers")},
+		{"follows the corpus", "/data/corpus", "", filepath.Join("/data/corpus", "crashers")},
+		{"set explicitly", "/data/corpus", "/data/crashes", "/data/crashes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			c.CorpusDir = tt.corpusDir
+			c.CrashDir = tt.crashDir
+			if got := c.CrashPath(); got != tt.want {
+				t.Errorf("CrashPath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// pkg/config/env.go
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of environment variables that override settings
+const EnvPrefix = "FUZZCTL_"
+
+// ApplyEnv overrides settings with FUZZCTL_* environment variables, e.g.
+// FUZZCTL_FUZZ_TIME=10m or FUZZCTL_PACKAGES=./lnwire/...,./tlv/...
+// lookup is usually os.LookupEnv.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	stringFields := map[string]*string{
+		"ROOT_DIR":   &c.RootDir,
+		"CORPUS_DIR": &c.CorpusDir,
+		"CRASH_DIR":  &c.CrashDir,
+		"REPORT_DIR": &c.ReportDir,
+		"GIT_REF":    &c.GitRef,
+		"STRATEGY":   &c.Strategy,
+		"IMPACT":     &c.Impact,
+	}
+	for name, field := range stringFields {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			*field = value
+		}
+	}
+
+	intFields := map[string]*int{
+		"PARALLELISM":        &c.Parallelism,
+		"CONCURRENT_TARGETS": &c.ConcurrentTargets,
+	}
+	for name, field := range intFields {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s: %w", EnvPrefix, name, err)
+			}
+			*field = n
+		}
+	}
+
+	boolFields := map[string]*bool{
+		"ALL_MODULES":           &c.AllModules,
+		"HARNESS_DETECTION":     &c.HarnessDetection,
+		"DISCOVERY_CACHE":       &c.DiscoveryCache,
+		"BINARY_CACHE":          &c.BinaryCache,
+		"CHANGED_ONLY":          &c.ChangedOnly,
+		"CRASH_SIGNATURE_LINES": &c.CrashSignatureLines,
+	}
+	for name, field := range boolFields {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s: %w", EnvPrefix, name, err)
+			}
+			*field = b
+		}
+	}
+
+	durationFields := map[string]*time.Duration{
+		"FUZZ_TIME":  &c.FuzzTime,
+		"BUDGET":     &c.Budget,
+		"ROUND_TIME": &c.RoundTime,
+	}
+	for name, field := range durationFields {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s%s: %w", EnvPrefix, name, err)
+			}
+			*field = d
+		}
+	}
+
+	if value, ok := lookup(EnvPrefix + "PACKAGES"); ok {
+		c.Packages = strings.Split(value, ",")
+	}
+
+	listFields := map[string]*[]string{
+		"BUILD_TAGS": &c.BuildTags,
+		"INCLUDE":    &c.Include,
+		"EXCLUDE":    &c.Exclude,
+		"ONLY_TAGS":  &c.OnlyTags,
+		"SKIP_TAGS":  &c.SkipTags,
+	}
+	for name, field := range listFields {
+		if value, ok := lookup(EnvPrefix + name); ok {
+			*field = nil
+			if value != "" {
+				*field = strings.Split(value, ",")
+			}
+		}
+	}
+
+	return nil
+}
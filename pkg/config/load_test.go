@@ -0,0 +1,233 @@
+// pkg/config/load_test.go
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a configuration file named name into a temporary
+// directory and returns its path
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		check   func(t *testing.T, c *Config)
+		err     string
+	}{
+		{
+			name:    "empty file keeps the defaults",
+			file:    "config.yaml",
+			content: "",
+			check: func(t *testing.T, c *Config) {
+				want := Default()
+				if c.FuzzTime != want.FuzzTime || c.Parallelism != want.Parallelism || c.CorpusDir != want.CorpusDir {
+					t.Errorf("config = %+v, want the defaults", c)
+				}
+				if !maps.Equal(c.TimeAllocation, want.TimeAllocation) {
+					t.Errorf("time_allocation = %v, want %v", c.TimeAllocation, want.TimeAllocation)
+				}
+			},
+		},
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `packages: [./lnwire/..., ./tlv/...]
+fuzz_time: 2m
+parallelism: 8
+strategy: adaptive
+build_tags: [dev]
+targets:
+  example.com/p.FuzzA:
+    fuzz_time: 30s
+    skip: true
+`,
+			check: func(t *testing.T, c *Config) {
+				if !slices.Equal(c.Packages, []string{"./lnwire/...", "./tlv/..."}) {
+					t.Errorf("packages = %v", c.Packages)
+				}
+				if c.FuzzTime != 2*time.Minute || c.Parallelism != 8 || c.Strategy != StrategyAdaptive {
+					t.Errorf("fuzz_time, parallelism, strategy = %v, %d, %s", c.FuzzTime, c.Parallelism, c.Strategy)
+				}
+				if !slices.Equal(c.BuildTags, []string{"dev"}) {
+					t.Errorf("build_tags = %v", c.BuildTags)
+				}
+				if tc, ok := c.Target("example.com/p", "FuzzA"); !ok || tc.FuzzTime != 30*time.Second || !tc.Skip {
+					t.Errorf("target = %+v, %v", tc, ok)
+				}
+				if c.CorpusDir != Default().CorpusDir {
+					t.Errorf("corpus_dir = %s, want the default", c.CorpusDir)
+				}
+			},
+		},
+		{
+			name:    "json",
+			file:    "config.json",
+			content: `{"fuzz_time": "90s", "parallelism": 2, "changed_only": true, "git_ref": "main", "include": ["lnwire"]}`,
+			check: func(t *testing.T, c *Config) {
+				if c.FuzzTime != 90*time.Second || c.Parallelism != 2 {
+					t.Errorf("fuzz_time, parallelism = %v, %d", c.FuzzTime, c.Parallelism)
+				}
+				if !c.ChangedOnly || c.GitRef != "main" || !slices.Equal(c.Include, []string{"lnwire"}) {
+					t.Errorf("changed_only, git_ref, include = %v, %s, %v", c.ChangedOnly, c.GitRef, c.Include)
+				}
+			},
+		},
+		{
+			name:    "time_allocation replaces the default",
+			file:    "config.yaml",
+			content: "time_allocation:\n  lnwire: 0.5\n  tlv: 0.25\n",
+			check: func(t *testing.T, c *Config) {
+				want := map[string]float64{"lnwire": 0.5, "tlv": 0.25}
+				if !maps.Equal(c.TimeAllocation, want) {
+					t.Errorf("time_allocation = %v, want %v", c.TimeAllocation, want)
+				}
+			},
+		},
+		{
+			name:    "unknown key",
+			file:    "config.yaml",
+			content: "fuzz_time: 1m\nparalelism: 8\n",
+			err:     "field paralelism not found",
+		},
+		{
+			name:    "unknown key in json",
+			file:    "config.json",
+			content: `{"targets": {"p.FuzzA": {"fuzztime": "1m"}}}`,
+			err:     "field fuzztime not found",
+		},
+		{
+			name:    "invalid duration",
+			file:    "config.yaml",
+			content: "fuzz_time: often\n",
+			err:     "failed to parse config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := LoadFromFile(writeConfig(t, tt.file, tt.content))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestLoadFromMissingFile(t *testing.T) {
+	if _, err := LoadFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	const file = `fuzz_time: 2m
+parallelism: 8
+corpus_dir: ./from-file
+build_tags: [dev]
+`
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(t *testing.T, c *Config)
+		err   string
+	}{
+		{
+			name: "file over defaults",
+			check: func(t *testing.T, c *Config) {
+				if c.FuzzTime != 2*time.Minute || c.Parallelism != 8 || c.CorpusDir != "./from-file" {
+					t.Errorf("fuzz_time, parallelism, corpus_dir = %v, %d, %s", c.FuzzTime, c.Parallelism, c.CorpusDir)
+				}
+				if c.ReportDir != Default().ReportDir {
+					t.Errorf("report_dir = %s, want the default", c.ReportDir)
+				}
+			},
+		},
+		{
+			name: "environment over file",
+			env: map[string]string{
+				"FUZZCTL_FUZZ_TIME":    "10m",
+				"FUZZCTL_PARALLELISM":  "2",
+				"FUZZCTL_CORPUS_DIR":   "./from-env",
+				"FUZZCTL_CHANGED_ONLY": "true",
+				"FUZZCTL_PACKAGES":     "./a/...,./b/...",
+			},
+			check: func(t *testing.T, c *Config) {
+				if c.FuzzTime != 10*time.Minute || c.Parallelism != 2 || c.CorpusDir != "./from-env" {
+					t.Errorf("fuzz_time, parallelism, corpus_dir = %v, %d, %s", c.FuzzTime, c.Parallelism, c.CorpusDir)
+				}
+				if !c.ChangedOnly || !slices.Equal(c.Packages, []string{"./a/...", "./b/..."}) {
+					t.Errorf("changed_only, packages = %v, %v", c.ChangedOnly, c.Packages)
+				}
+			},
+		},
+		{
+			name: "empty list clears the file's",
+			env:  map[string]string{"FUZZCTL_BUILD_TAGS": ""},
+			check: func(t *testing.T, c *Config) {
+				if len(c.BuildTags) != 0 {
+					t.Errorf("build_tags = %v, want none", c.BuildTags)
+				}
+			},
+		},
+		{
+			name: "invalid number",
+			env:  map[string]string{"FUZZCTL_PARALLELISM": "many"},
+			err:  "invalid FUZZCTL_PARALLELISM",
+		},
+		{
+			name: "invalid duration",
+			env:  map[string]string{"FUZZCTL_BUDGET": "1 hour"},
+			err:  "invalid FUZZCTL_BUDGET",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			c, err := LoadFromFile(writeConfig(t, "config.yaml", file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = c.ApplyEnv(os.LookupEnv)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// pkg/config/validate.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError describes a problem with a single setting
+type FieldError struct {
+	// Field is the configuration key, e.g. "time_allocation.lnwire"
+	Field   string
+	Message string
+
+	// File and Line locate the setting when it was loaded from a file
+	File string
+	Line int
+}
+
+// Error implements the error interface
+func (e *FieldError) Error() string {
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s: %s", e.File, e.Line, e.Field, e.Message)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s: %s", e.File, e.Field, e.Message)
+	default:
+		return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	}
+}
+
+// ValidationError collects every problem found in a configuration
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "invalid configuration:\n  " + strings.Join(msgs, "\n  ")
+}
+
+// source records where a configuration was loaded from
+type source struct {
+	path  string
+	lines map[string]int
+}
+
+// Validate checks the configuration and reports all problems at once as a
+// *ValidationError
+func (c *Config) Validate() error {
+	var errs []*FieldError
+	add := func(field, format string, args ...any) {
+		errs = append(errs, c.fieldError(field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.RootDir == "" {
+		add("root_dir", "must be set")
+	} else if info, err := os.Stat(c.RootDir); err != nil || !info.IsDir() {
+		add("root_dir", "%q is not a directory", c.RootDir)
+	}
+	if len(c.Packages) == 0 {
+		add("packages", "at least one package pattern is required")
+	}
+	if c.CorpusDir == "" {
+		add("corpus_dir", "must be set")
+	}
+	for _, tag := range c.BuildTags {
+		if tag == "" || strings.ContainsAny(tag, ", \t") {
+			add("build_tags", "invalid build tag %q", tag)
+		}
+	}
+	for _, field := range []struct {
+		name  string
+		exprs []string
+	}{{"include", c.Include}, {"exclude", c.Exclude}} {
+		for _, expr := range field.exprs {
+			if _, err := regexp.Compile(expr); err != nil {
+				add(field.name, "invalid regular expression %q: %v", expr, err)
+			}
+		}
+	}
+	for _, tag := range c.OnlyTags {
+		if tag == "" || strings.ContainsAny(tag, ", \t") {
+			add("only_tags", "invalid tag %q", tag)
+		}
+	}
+	for _, tag := range c.SkipTags {
+		if tag == "" || strings.ContainsAny(tag, ", \t") {
+			add("skip_tags", "invalid tag %q", tag)
+		}
+	}
+	if c.ReportDir == "" {
+		add("report_dir", "must be set")
+	}
+	if c.FuzzTime <= 0 {
+		add("fuzz_time", "must be positive, got %s", c.FuzzTime)
+	}
+	if c.Budget < 0 {
+		add("budget", "must not be negative, got %s (use 0 to give each target fuzz_time)", c.Budget)
+	}
+	switch c.Strategy {
+	case StrategyFixed:
+	case StrategyAdaptive:
+		if c.RoundTime < time.Second {
+			add("round_time", "must be at least 1s, got %s", c.RoundTime)
+		}
+	default:
+		add("strategy", "must be %q or %q, got %q", StrategyFixed, StrategyAdaptive, c.Strategy)
+	}
+	if c.Parallelism < 1 {
+		add("parallelism", "must be at least 1, got %d", c.Parallelism)
+	}
+	if c.ConcurrentTargets < 0 {
+		add("concurrent_targets", "must not be negative, got %d (use 0 to derive it from parallelism)", c.ConcurrentTargets)
+	} else if c.Parallelism >= 1 && c.ConcurrentTargets > c.Parallelism {
+		add("concurrent_targets", "%d targets can't share %d parallel processes; lower it or raise parallelism",
+			c.ConcurrentTargets, c.Parallelism)
+	}
+	if c.Impact != ImpactPackage && c.Impact != ImpactFunction {
+		add("impact", "must be %q or %q, got %q", ImpactPackage, ImpactFunction, c.Impact)
+	}
+	if c.ChangedOnly && c.GitRef == "" {
+		add("git_ref", "must be set when changed_only is enabled")
+	}
+
+	// Whether the fractions add up to more than 1.0 depends on the keys
+	// targets resolve to, see OverAllocation
+	for _, key := range sortedKeys(c.TimeAllocation) {
+		if weight := c.TimeAllocation[key]; weight < 0 {
+			add("time_allocation."+key, "must not be negative, got %g", weight)
+		}
+	}
+
+	for _, key := range sortedKeys(c.Targets) {
+		if !isTargetKey(key) {
+			add("targets."+key, `must be "<package>.<FuzzName>"`)
+		}
+		if c.Targets[key].FuzzTime < 0 {
+			add("targets."+key+".fuzz_time", "must not be negative, got %s", c.Targets[key].FuzzTime)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// UnusedKeys reports time allocation and target keys that don't match any
+// of the given "<package>.<FuzzName>" targets. These are not errors, but
+// usually a typo or a stale entry.
+func (c *Config) UnusedKeys(targets []string) []*FieldError {
+	var warnings []*FieldError
+
+	for _, key := range sortedKeys(c.TimeAllocation) {
+		if !allocationKeyUsed(key, targets) {
+			warnings = append(warnings, c.fieldError("time_allocation."+key,
+				"does not match any discovered package or target"))
+		}
+	}
+
+	for _, key := range sortedKeys(c.Targets) {
+		if slices.Contains(targets, key) {
+			continue
+		}
+		warnings = append(warnings, c.fieldError("targets."+key,
+			"does not match any discovered fuzz target"))
+	}
+
+	return warnings
+}
+
+// OverAllocation reports when the time allocation keys the given
+// "<package>.<FuzzName>" targets resolve to add up to more than 1.0. Every
+// target counts the one key that wins for it, so a target key overriding
+// its package key for some targets doesn't count twice where it applies.
+// Keys shared by several targets are counted once.
+func (c *Config) OverAllocation(targets []string) *FieldError {
+	used := make(map[string]bool)
+	for _, t := range targets {
+		i := strings.LastIndex(t, ".")
+		if key, _ := c.Allocation(t[:i], t[i+1:]); key != "" {
+			used[key] = true
+		}
+	}
+
+	total := 0.0
+	keys := sortedKeys(used)
+	for _, key := range keys {
+		total += c.TimeAllocation[key]
+	}
+	if total <= 1.0+1e-9 {
+		return nil
+	}
+
+	return c.fieldError("time_allocation", fmt.Sprintf(
+		"fractions of the keys used by the discovered targets add up to %g, more than 1.0 (%s)",
+		total, strings.Join(keys, ", ")))
+}
+
+// allocationKeyUsed reports whether a time allocation key matches at least
+// one of the given targets
+func allocationKeyUsed(key string, targets []string) bool {
+	for _, t := range targets {
+		i := strings.LastIndex(t, ".")
+		if _, kind := matchAllocationKey(key, t[:i], t[i+1:]); kind != matchNone {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldError creates a FieldError located in the file the configuration
+// was loaded from, if any
+func (c *Config) fieldError(field, message string) *FieldError {
+	err := &FieldError{Field: field, Message: message}
+	if c.source != nil {
+		err.File = c.source.path
+		err.Line = c.source.line(field)
+	}
+	return err
+}
+
+// line returns the line of a field, falling back to its closest parent
+func (s *source) line(field string) int {
+	for field != "" {
+		if line, ok := s.lines[field]; ok {
+			return line
+		}
+
+		i := strings.LastIndex(field, ".")
+		if i < 0 {
+			break
+		}
+		field = field[:i]
+	}
+	return 0
+}
+
+// recordLines maps the dotted path of every key in a YAML document to the
+// line it appears on
+func recordLines(node *yaml.Node, prefix string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			recordLines(child, prefix, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			lines[key] = node.Content[i].Line
+			recordLines(node.Content[i+1], key, lines)
+		}
+	}
+}
+
+// isTargetKey reports whether key has the form "<package>.<FuzzName>"
+func isTargetKey(key string) bool {
+	i := strings.LastIndex(key, ".")
+	return i > 0 && strings.HasPrefix(key[i+1:], "Fuzz") && !strings.Contains(key[i+1:], "/")
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
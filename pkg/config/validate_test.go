@@ -0,0 +1,261 @@
+// pkg/config/validate_test.go
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fields returns the fields of the problems reported by Validate
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error %v is not a *ValidationError", err)
+	}
+
+	var names []string
+	for _, e := range verr.Errors {
+		names = append(names, e.Field)
+	}
+	return names
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		fields []string
+	}{
+		{
+			name:   "defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "more concurrent targets than parallel processes",
+			modify: func(c *Config) {
+				c.Parallelism = 2
+				c.ConcurrentTargets = 3
+			},
+			fields: []string{"concurrent_targets"},
+		},
+		{
+			name: "as many concurrent targets as parallel processes",
+			modify: func(c *Config) {
+				c.Parallelism = 2
+				c.ConcurrentTargets = 2
+			},
+		},
+		{
+			name: "negative concurrent targets",
+			modify: func(c *Config) {
+				c.ConcurrentTargets = -1
+			},
+			fields: []string{"concurrent_targets"},
+		},
+		{
+			name: "no parallelism",
+			modify: func(c *Config) {
+				c.Parallelism = 0
+				c.ConcurrentTargets = 3
+			},
+			fields: []string{"parallelism"},
+		},
+		{
+			name: "changed_only without git_ref",
+			modify: func(c *Config) {
+				c.ChangedOnly = true
+				c.GitRef = ""
+			},
+			fields: []string{"git_ref"},
+		},
+		{
+			name: "git_ref unset without changed_only",
+			modify: func(c *Config) {
+				c.GitRef = ""
+			},
+		},
+		{
+			name: "short rounds",
+			modify: func(c *Config) {
+				c.Strategy = StrategyAdaptive
+				c.RoundTime = 100 * time.Millisecond
+			},
+			fields: []string{"round_time"},
+		},
+		{
+			name: "short rounds of the fixed strategy",
+			modify: func(c *Config) {
+				c.RoundTime = 0
+			},
+		},
+		{
+			name: "every problem at once",
+			modify: func(c *Config) {
+				c.Packages = nil
+				c.FuzzTime = 0
+				c.Strategy = "random"
+				c.Impact = "all"
+				c.Include = []string{"("}
+				c.BuildTags = []string{"a,b"}
+				c.Targets = map[string]TargetConfig{"lnwire": {FuzzTime: -time.Second}}
+			},
+			fields: []string{"packages", "build_tags", "include", "fuzz_time", "strategy", "impact", "targets.lnwire", "targets.lnwire.fuzz_time"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.modify(c)
+			if got := fields(t, c.Validate()); !slices.Equal(got, tt.fields) {
+				t.Errorf("problems with %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestFieldLocations(t *testing.T) {
+	const file = `packages: []
+parallelism: 2
+concurrent_targets: 3
+changed_only: true
+git_ref: ""
+strategy: adaptive
+round_time: 100ms
+time_allocation:
+  example.com/p: 0.6
+  example.com/p.FuzzA: 0.7
+  lnwire: -0.5
+targets:
+  example.com/p.FuzzA:
+    fuzz_time: -1s
+  notatarget:
+    skip: true
+`
+
+	path := writeConfig(t, "fuzzctl.yaml", file)
+	c, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var verr *ValidationError
+	if !errors.As(c.Validate(), &verr) {
+		t.Fatal("configuration is valid")
+	}
+
+	type location struct {
+		field string
+		line  int
+	}
+	locations := func(errs []*FieldError) []location {
+		var got []location
+		for _, e := range errs {
+			if e.File != path {
+				t.Errorf("%s is in %s, want %s", e.Field, e.File, path)
+			}
+			got = append(got, location{e.Field, e.Line})
+		}
+		return got
+	}
+
+	tests := []struct {
+		name string
+		errs []*FieldError
+		want []location
+	}{
+		{
+			name: "validation",
+			errs: verr.Errors,
+			want: []location{
+				{"packages", 1},
+				{"round_time", 7},
+				{"concurrent_targets", 3},
+				{"git_ref", 5},
+				{"time_allocation.lnwire", 11},
+				{"targets.example.com/p.FuzzA.fuzz_time", 14},
+				{"targets.notatarget", 15},
+			},
+		},
+		{
+			name: "unused keys",
+			errs: c.UnusedKeys([]string{"example.com/q.FuzzB"}),
+			want: []location{
+				{"time_allocation.example.com/p", 9},
+				{"time_allocation.example.com/p.FuzzA", 10},
+				{"time_allocation.lnwire", 11},
+				{"targets.example.com/p.FuzzA", 13},
+				{"targets.notatarget", 15},
+			},
+		},
+		{
+			name: "target key overriding its package key",
+			errs: []*FieldError{c.OverAllocation([]string{"example.com/p.FuzzA", "example.com/p.FuzzB"})},
+			want: []location{{"time_allocation", 8}},
+		},
+	}
+
+	// Only the target key applies when the package has no other targets
+	if w := c.OverAllocation([]string{"example.com/p.FuzzA"}); w != nil {
+		t.Errorf("unexpected warning: %v", w)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := locations(tt.errs); !slices.Equal(got, tt.want) {
+				t.Errorf("locations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceLine(t *testing.T) {
+	s := &source{lines: map[string]int{
+		"targets":                     10,
+		"targets.example.com/p.FuzzA": 11,
+		"time_allocation":             3,
+	}}
+
+	tests := []struct {
+		field string
+		want  int
+	}{
+		{"targets", 10},
+		{"targets.example.com/p.FuzzA", 11},
+		{"targets.example.com/p.FuzzA.fuzz_time", 11},
+		{"targets.example.com/q.FuzzB.fuzz_time", 10},
+		{"time_allocation.lnwire", 3},
+		{"git_ref", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := s.line(tt.field); got != tt.want {
+			t.Errorf("line(%q) = %d, want %d", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestFieldError(t *testing.T) {
+	tests := []struct {
+		err  *FieldError
+		want string
+	}{
+		{&FieldError{Field: "fuzz_time", Message: "must be positive", File: "f.yaml", Line: 3}, "f.yaml:3: fuzz_time: must be positive"},
+		{&FieldError{Field: "git_ref", Message: "must be set", File: "f.yaml"}, "f.yaml: git_ref: must be set"},
+		{&FieldError{Field: "git_ref", Message: "must be set"}, "git_ref: must be set"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}